@@ -0,0 +1,42 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// requireAdmin only lets requests carrying the admin token as a bearer token through.
+// Admin endpoints are disabled when no admin token is configured.
+func (a *api) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.config.AdminToken == "" {
+			writeJsonMessage(w, map[string]any{
+				"error": "admin endpoints are disabled",
+			}, http.StatusForbidden)
+
+			return
+		}
+
+		if !a.isAdmin(r) {
+			a.logger(r.Context()).Warn("unauthorized admin request", "path", r.URL.Path, "client_ip", a.clientIP(r))
+			writeJsonMessage(w, map[string]any{
+				"error": "unauthorized",
+			}, http.StatusUnauthorized)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// isAdmin reports whether the request carries the admin token as a bearer token
+func (a *api) isAdmin(r *http.Request) bool {
+	if a.config.AdminToken == "" {
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.config.AdminToken)) == 1
+}
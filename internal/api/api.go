@@ -1,35 +1,105 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"net/http/pprof"
+	"net/netip"
 	"time"
 
-	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	"github.com/navikt/pleesah-havnesjef/internal/audit"
+	"github.com/navikt/pleesah-havnesjef/internal/notify"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type api struct {
-	k8s    k8s.Client
+	k8s    Provisioner
 	log    *slog.Logger
 	server *http.Server
+	config Config
 }
 
-func New(client k8s.Client, log *slog.Logger) api {
+type Config struct {
+	AdminToken string
+	// RulesURL points at the rules teams must accept before they are created, when set
+	RulesURL string
+	// JoinCode must be given as code when creating teams, which then get a suffix derived from it
+	JoinCode string
+	// TrustedProxies are the proxies whose X-Forwarded-For and X-Real-IP headers are believed
+	TrustedProxies []netip.Prefix
+	// MaxBodyBytes is the largest request body handlers will read
+	MaxBodyBytes int64
+	// Audit records team operations, and is disabled when nil
+	Audit *audit.Logger
+	// Notifier is told about new teams, and is disabled when nil
+	Notifier *notify.Notifier
+	// BlockedNames are names and glob patterns teams can not use
+	BlockedNames []string
+	// MaxTeamNameLength is the longest team name accepted
+	MaxTeamNameLength int
+	// EnablePprof serves net/http/pprof under /debug/pprof/ for admins
+	EnablePprof bool
+	// BasePath is the subpath the API is served under, e.g. /havnesjef
+	BasePath string
+	// RequestTimeout bounds how long a handler may run before the client gets a 503
+	RequestTimeout time.Duration
+	// InsecureKubeconfig tells clients that kubeconfigs skip TLS verification
+	InsecureKubeconfig bool
+}
+
+func New(client Provisioner, log *slog.Logger, config Config) api {
 	a := api{
-		k8s: client,
-		log: log,
+		k8s:    client,
+		log:    log,
+		config: config,
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/api/v1/team/", http.StripPrefix("/api/v1/team", a.TeamHandler()))
 	mux.HandleFunc("GET /api/v1/teams", a.TreasureMapHandler)
+	mux.HandleFunc("POST /api/v1/teams/purge", a.requireAdmin(a.PurgeHandler))
+	mux.HandleFunc("POST "+classroomPath, a.requireAdmin(a.ClassroomHandler))
+	mux.HandleFunc("POST "+kubeconfigsPath, a.requireAdmin(a.KubeconfigsHandler))
+	mux.HandleFunc("GET /api/v1/slots", a.SlotsHandler)
+	mux.HandleFunc("POST /api/v1/whoami", a.requireAdmin(a.WhoamiHandler))
+	mux.HandleFunc("GET /api/v1/preview", a.requireAdmin(a.PreviewHandler))
+	mux.HandleFunc("GET /api/v1/scoreboard", a.ScoreboardHandler)
+	mux.HandleFunc("GET /api/v1/version", a.VersionHandler)
+	mux.HandleFunc("GET /api/v1/status", a.requireAdmin(a.StatusSummaryHandler))
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /readyz", a.ReadyHandler)
+	if config.EnablePprof {
+		mux.HandleFunc("GET /debug/pprof/", a.requireAdmin(pprof.Index))
+		mux.HandleFunc("GET /debug/pprof/cmdline", a.requireAdmin(pprof.Cmdline))
+		mux.HandleFunc("GET /debug/pprof/profile", a.requireAdmin(a.profileDeadline(pprof.Profile)))
+		mux.HandleFunc("GET /debug/pprof/symbol", a.requireAdmin(pprof.Symbol))
+		mux.HandleFunc("GET /debug/pprof/trace", a.requireAdmin(a.profileDeadline(pprof.Trace)))
+	}
+
+	var handler http.Handler = mux
+	if config.BasePath != "" {
+		root := http.NewServeMux()
+		root.Handle(config.BasePath+"/", http.StripPrefix(config.BasePath, mux))
+		// probes and scraping go straight to the pod, not through the ingress adding the base path
+		root.Handle("GET /metrics", promhttp.Handler())
+		root.HandleFunc("GET /readyz", a.ReadyHandler)
+		handler = root
+	}
+
+	// Leave room for the timeout response to be written before the connection is cut
+	writeTimeout := 10 * time.Second
+	if config.RequestTimeout > 0 {
+		writeTimeout = config.RequestTimeout + 5*time.Second
+	}
 
 	server := &http.Server{
 		Addr:           ":8080",
-		Handler:        mux,
+		Handler:        requestID(securityHeaders(a.recoverer(a.timeout(a.limitBody(handler))))),
 		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
+		WriteTimeout:   writeTimeout,
 		MaxHeaderBytes: 1 << 20,
 	}
 
@@ -37,10 +107,32 @@ func New(client k8s.Client, log *slog.Logger) api {
 	return a
 }
 
-func (a api) Run() {
-	a.log.Info("Running on :8080")
-	if err := a.server.ListenAndServe(); err != nil {
-		panic(err.Error())
+// Run serves the API until ctx is done, with TLS when both certFile and keyFile are set.
+func (a api) Run(ctx context.Context, certFile, keyFile string) {
+	errs := make(chan error, 1)
+	go func() {
+		if certFile != "" && keyFile != "" {
+			a.log.Info("Running with TLS on :8080")
+			errs <- a.server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			a.log.Info("Running on :8080")
+			errs <- a.server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			panic(err.Error())
+		}
+	case <-ctx.Done():
+		a.log.Info("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := a.server.Shutdown(shutdownCtx); err != nil {
+			a.log.Error("failed shutting down", "error", err)
+		}
 	}
 }
 
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/audit"
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+)
+
+const (
+	maxClassroomTeams = 100
+	classroomPath     = "/api/v1/teams/classroom"
+)
+
+// Example: POST /api/v1/teams/classroom?prefix={string}&count={int}&hex={code}&format={zip}
+// Creates teams named {prefix}-01 to {prefix}-{count}, and responds with what was created for each,
+// or a zip of the kubeconfigs when format=zip. Instead of RequestTimeout for the whole request, every team gets RequestTimeout.
+func (a *api) ClassroomHandler(w http.ResponseWriter, r *http.Request) {
+	prefix := normalizeTeamName(r.URL.Query().Get("prefix"))
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 1 || count > maxClassroomTeams {
+		writeJsonMessage(w, map[string]any{
+			"error": fmt.Sprintf("count must be between 1 and %d", maxClassroomTeams),
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	hexcode := r.URL.Query().Get("hex")
+	if hexcode != "" && !validateHexcode(hexcode) {
+		writeJsonMessage(w, map[string]any{
+			"error": "hex is not valid",
+			"code":  CODE_INVALID_HEX,
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	teams := classroomTeams(prefix, count)
+	for _, team := range teams {
+		if err := validateTeam(team, a.config.MaxTeamNameLength); err != nil {
+			writeJsonMessage(w, map[string]any{
+				"error": err.Error(),
+				"team":  team,
+				"code":  errorCode(err),
+			}, http.StatusBadRequest)
+
+			return
+		}
+
+		if blockedName(team, a.config.BlockedNames) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team name is not allowed, please pick another prefix",
+				"team":  team,
+				"code":  CODE_NAME_BLOCKED,
+			}, http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if a.config.RequestTimeout > 0 {
+		timeout := time.Duration(count) * a.config.RequestTimeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 5*time.Second)); err != nil {
+			a.logger(r.Context()).Warn("failed extending write deadline for classroom", "error", err)
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	created := []k8s.TeamResult{}
+	failed := map[string]string{}
+	for _, team := range teams {
+		result, err := a.k8s.SetupTeamResult(r.Context(), team, hexcode, k8s.PLAYER_ROLE, 0)
+		a.audit(r, audit.CREATE, team, k8s.PLAYER_ROLE, err)
+		if err != nil {
+			a.logger(r.Context()).Error("failed creating classroom team", "error", err, "team", team)
+			failed[team] = err.Error()
+			continue
+		}
+
+		a.config.Notifier.TeamCreated(a.logger(r.Context()), team, k8s.PLAYER_ROLE)
+		created = append(created, result)
+	}
+
+	a.logger(r.Context()).Info("Created classroom", "prefix", prefix, "created", len(created), "failed", len(failed))
+	a.warnInsecureKubeconfig(w)
+
+	if r.URL.Query().Get("format") == "zip" {
+		kubeconfigs := map[string]string{}
+		for _, result := range created {
+			kubeconfigs[result.Team] = result.Kubeconfig
+		}
+
+		a.writeKubeconfigZip(w, r, kubeconfigs, failed)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if len(failed) > 0 {
+		statusCode = http.StatusMultiStatus
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"teams":  created,
+		"failed": failed,
+	}, statusCode)
+}
+
+// classroomTeams numbers count teams after prefix, zero-padded to at least two digits
+func classroomTeams(prefix string, count int) []string {
+	width := max(len(strconv.Itoa(count)), 2)
+	teams := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		teams = append(teams, fmt.Sprintf("%s-%0*d", prefix, width, i))
+	}
+
+	return teams
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIP is the address of the client, read from X-Forwarded-For or X-Real-IP when the request
+// comes through one of the TrustedProxies. RemoteAddr may be an IPv6 address in brackets.
+func (a *api) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !a.trustedProxy(remote) {
+		return host
+	}
+
+	// Each proxy appends the address it got the request from, so the client is the rightmost address that is not a proxy
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+
+		if !a.trustedProxy(addr) {
+			return addr.String()
+		}
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.String()
+	}
+
+	return host
+}
+
+func (a *api) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range a.config.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
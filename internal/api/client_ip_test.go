@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	a := &api{config: Config{
+		TrustedProxies: []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("fd00::/8"),
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{name: "ipv4", remoteAddr: "192.0.2.10:51234", want: "192.0.2.10"},
+		{name: "ipv6", remoteAddr: "[2001:db8::1]:51234", want: "2001:db8::1"},
+		{name: "no port", remoteAddr: "192.0.2.10", want: "192.0.2.10"},
+		{name: "untrusted proxy is ignored", remoteAddr: "192.0.2.10:51234", forwarded: "198.51.100.7", want: "192.0.2.10"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:51234", forwarded: "198.51.100.7", want: "198.51.100.7"},
+		{name: "trusted ipv6 proxy", remoteAddr: "[fd00::5]:51234", forwarded: "2001:db8::7", want: "2001:db8::7"},
+		{name: "chain of proxies", remoteAddr: "10.1.2.3:51234", forwarded: "198.51.100.7, 10.9.9.9", want: "198.51.100.7"},
+		{name: "spoofed first hop", remoteAddr: "10.1.2.3:51234", forwarded: "203.0.113.66, 198.51.100.7", want: "198.51.100.7"},
+		{name: "x-real-ip", remoteAddr: "10.1.2.3:51234", realIP: "198.51.100.7", want: "198.51.100.7"},
+		{name: "garbage forwarded falls back to x-real-ip", remoteAddr: "10.1.2.3:51234", forwarded: "unknown", realIP: "198.51.100.7", want: "198.51.100.7"},
+		{name: "trusted proxy without headers", remoteAddr: "10.1.2.3:51234", want: "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := a.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/audit"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const kubeconfigsPath = "/api/v1/teams/kubeconfigs"
+
+// Example: POST /api/v1/teams/kubeconfigs
+// Payload: {"teams": ["team-01", "team-02"]}
+// Responds with a zip of fresh kubeconfigs, one <team>/config per team, and failed.json listing teams that failed.
+// Instead of RequestTimeout for the whole request, every team gets RequestTimeout.
+func (a *api) KubeconfigsHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Teams []string `json:"teams"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJsonMessage(w, map[string]any{
+				"error": fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit),
+			}, http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		writeJsonMessage(w, map[string]any{
+			"error": "failed parsing body",
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	if len(body.Teams) == 0 {
+		writeJsonMessage(w, map[string]any{
+			"error": "teams can not be empty",
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	teams := make([]string, 0, len(body.Teams))
+	for _, team := range body.Teams {
+		team = normalizeTeamName(team)
+		if err := validateTeam(team, a.config.MaxTeamNameLength); err != nil {
+			writeJsonMessage(w, map[string]any{
+				"error": err.Error(),
+				"team":  team,
+				"code":  errorCode(err),
+			}, http.StatusBadRequest)
+
+			return
+		}
+
+		if !slices.Contains(teams, team) {
+			teams = append(teams, team)
+		}
+	}
+
+	a.warnInsecureKubeconfig(w)
+	archive := startKubeconfigZip(w)
+	failed := map[string]string{}
+	for _, team := range teams {
+		k8sconfig, err := a.reissueToken(w, r, team)
+		a.audit(r, audit.RENEW, team, "", err)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				failed[team] = "team was not found"
+				continue
+			}
+
+			a.logger(r.Context()).Error("failed renewing token", "error", err, "team", team)
+			failed[team] = "failed renewing token"
+			continue
+		}
+
+		// written as soon as it is ready, so the zip streams instead of waiting for every team
+		if err := writeKubeconfigEntry(archive, team, k8sconfig); err != nil {
+			a.logger(r.Context()).Error("failed writing kubeconfig zip", "error", err, "team", team)
+			return
+		}
+	}
+
+	a.finishKubeconfigZip(r, archive, failed)
+}
+
+// reissueToken gives every team RequestTimeout, instead of RequestTimeout for the whole request
+func (a *api) reissueToken(w http.ResponseWriter, r *http.Request, team string) (string, error) {
+	if a.config.RequestTimeout <= 0 {
+		return a.k8s.ReissueToken(r.Context(), team)
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(a.config.RequestTimeout + 5*time.Second)); err != nil {
+		a.logger(r.Context()).Warn("failed extending write deadline for kubeconfigs", "error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), a.config.RequestTimeout)
+	defer cancel()
+	return a.k8s.ReissueToken(ctx, team)
+}
+
+// writeKubeconfigZip writes kubeconfigs as <team>/config entries, with failed.json listing teams that failed
+func (a *api) writeKubeconfigZip(w http.ResponseWriter, r *http.Request, kubeconfigs, failed map[string]string) {
+	archive := startKubeconfigZip(w)
+	for _, team := range slices.Sorted(maps.Keys(kubeconfigs)) {
+		if err := writeKubeconfigEntry(archive, team, kubeconfigs[team]); err != nil {
+			// the status is already sent, so all that is left is to log and stop
+			a.logger(r.Context()).Error("failed writing kubeconfig zip", "error", err, "team", team)
+			return
+		}
+	}
+
+	a.finishKubeconfigZip(r, archive, failed)
+}
+
+func startKubeconfigZip(w http.ResponseWriter) *zip.Writer {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="kubeconfigs.zip"`)
+	return zip.NewWriter(w)
+}
+
+func writeKubeconfigEntry(archive *zip.Writer, team, kubeconfig string) error {
+	entry, err := archive.Create(team + "/config")
+	if err != nil {
+		return err
+	}
+
+	_, err = entry.Write([]byte(kubeconfig))
+	return err
+}
+
+// finishKubeconfigZip adds failed.json when teams failed, and closes the zip
+func (a *api) finishKubeconfigZip(r *http.Request, archive *zip.Writer, failed map[string]string) {
+	if len(failed) > 0 {
+		entry, err := archive.Create("failed.json")
+		if err == nil {
+			err = json.NewEncoder(entry).Encode(failed)
+		}
+
+		if err != nil {
+			a.logger(r.Context()).Error("failed writing kubeconfig zip", "error", err)
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		a.logger(r.Context()).Error("failed writing kubeconfig zip", "error", err)
+	}
+}
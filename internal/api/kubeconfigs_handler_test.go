@@ -0,0 +1,63 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	"github.com/navikt/pleesah-havnesjef/internal/k8s/k8smock"
+)
+
+func postKubeconfigs(a api, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, kubeconfigsPath, strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer admin")
+	a.server.Handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestKubeconfigs(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{AdminToken: "admin"})
+	if recorder, _ := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000"); recorder.Code != http.StatusOK {
+		t.Fatalf("creating team: status = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	recorder := postKubeconfigs(a, `{"teams": ["team-a", "Team-A", "team-b"]}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", file.Name, err)
+		}
+
+		content, _ := io.ReadAll(reader)
+		files[file.Name] = string(content)
+	}
+
+	if len(files) != 2 || files["team-a/config"] == "" || !strings.Contains(files["failed.json"], "team-b") {
+		t.Errorf("expected team-a/config once and team-b in failed.json, got %v", files)
+	}
+}
+
+func TestKubeconfigsInvalidName(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{AdminToken: "admin"})
+
+	recorder := postKubeconfigs(a, `{"teams": ["team-a", "team_b"]}`)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), k8s.CODE_INVALID_NAME) {
+		t.Errorf("expected %s, got %d: %s", k8s.CODE_INVALID_NAME, recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/request"
+)
+
+// recoverer keeps the server running when a handler panics, and responds with a 500 instead.
+func (a *api) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			a.logger(r.Context()).Error("recovered from panic", "error", err, "method", r.Method, "path", r.URL.Path, "stack", string(debug.Stack()))
+			writeJsonMessage(w, map[string]any{
+				"error": "internal server error",
+			}, http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestID puts a request id in the request context, reusing X-Request-Id from the client when it is valid.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if !request.ValidID(id) {
+			id = request.NewID()
+		}
+
+		w.Header().Set("X-Request-Id", id)
+		next.ServeHTTP(w, r.WithContext(request.WithID(r.Context(), id)))
+	})
+}
+
+// securityHeaders locks down every response. The API only serves JSON, so nothing needs to be loaded or framed.
+func securityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logger returns the api logger with the request id from ctx
+func (a *api) logger(ctx context.Context) *slog.Logger {
+	if id := request.ID(ctx); id != "" {
+		return a.log.With("request_id", id)
+	}
+
+	return a.log
+}
+
+// timeout answers 503 when the handler runs longer than RequestTimeout. Panics in the handler
+// are passed on by http.TimeoutHandler, so recoverer still catches them.
+func (a *api) timeout(next http.Handler) http.Handler {
+	if a.config.RequestTimeout <= 0 {
+		return next
+	}
+
+	timeoutHandler := http.TimeoutHandler(next, a.config.RequestTimeout, `{"error":"request timed out"}`+"\n")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// classrooms and kubeconfigs take a while per team, and profiles run for as long as they are asked to, so they set their own deadlines
+		if r.URL.Path == a.config.BasePath+classroomPath || r.URL.Path == a.config.BasePath+kubeconfigsPath ||
+			strings.HasPrefix(r.URL.Path, a.config.BasePath+"/debug/pprof/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Only the timeout response relies on this, handlers set their own content type
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		start := time.Now()
+		timeoutHandler.ServeHTTP(w, r)
+
+		if time.Since(start) >= a.config.RequestTimeout {
+			a.logger(r.Context()).Warn("request timed out", "method", r.Method, "path", r.URL.Path, "timeout", a.config.RequestTimeout)
+		}
+	})
+}
+
+// profileDeadline extends the write deadline past the seconds a pprof profile or trace runs for, 30 by default like pprof
+func (a *api) profileDeadline(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seconds, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+		if err != nil || seconds <= 0 {
+			seconds = 30
+		}
+
+		deadline := time.Now().Add(time.Duration(seconds*float64(time.Second)) + 5*time.Second)
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			a.logger(r.Context()).Warn("failed extending write deadline for profile", "error", err)
+		}
+
+		next(w, r)
+	}
+}
+
+// limitBody caps how large request bodies handlers are allowed to read.
+func (a *api) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, a.config.MaxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// audit records a team operation in the audit log
+func (a *api) audit(r *http.Request, action, team, role string, err error) {
+	if err := a.config.Audit.Record(r.Context(), action, team, role, a.clientIP(r), err); err != nil {
+		a.logger(r.Context()).Error("failed writing audit record", "error", err, "action", action, "team", team)
+	}
+}
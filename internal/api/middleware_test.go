@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s/k8smock"
+)
+
+func TestTimeoutSkipsPprof(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{AdminToken: "admin", EnablePprof: true, RequestTimeout: 50 * time.Millisecond})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/debug/pprof/trace?seconds=0.2", nil)
+	request.Header.Set("Authorization", "Bearer admin")
+	a.server.Handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK || recorder.Body.Len() == 0 {
+		t.Errorf("expected a trace outlasting the request timeout, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Example: GET /api/v1/preview
+// Renders the kubeconfig template with placeholder values, as it is handed out but indented
+func (a *api) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+	kubeconfig, err := a.k8s.PreviewKubeconfig()
+	if err != nil {
+		a.logger(r.Context()).Error("failed rendering kubeconfig preview", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed rendering kubeconfig template",
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = w.Write([]byte(kubeconfig))
+}
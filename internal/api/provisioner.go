@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+// Provisioner is what the api needs from the cluster, implemented by k8s.Client
+type Provisioner interface {
+	SetupTeamResult(ctx context.Context, team, hexcode, role string, ttl time.Duration) (k8s.TeamResult, error)
+	SetupTeamExec(ctx context.Context, team, hexcode, role string, replace bool) (k8s.TeamResult, error)
+	RenewToken(ctx context.Context, team string) (string, error)
+	ReissueToken(ctx context.Context, team string) (string, error)
+	RotateServiceAccount(ctx context.Context, team string) (string, error)
+	ExecKubeconfig(ctx context.Context, team string) (string, error)
+	PreviewKubeconfig() (string, error)
+	ValidExecKey(ctx context.Context, team, key string) bool
+	ExecCredential(ctx context.Context, team string) (clientauthv1.ExecCredential, error)
+	GetTeam(ctx context.Context, team string) (k8s.Team, error)
+	ListTeams(ctx context.Context) ([]k8s.Team, error)
+	ListTeamsPage(ctx context.Context, limit int64, continueToken string) ([]k8s.Team, string, error)
+	ListSpectators(ctx context.Context) ([]k8s.Team, error)
+	GetTeamSlots(ctx context.Context) (k8s.TeamSlots, error)
+	DeleteTeam(ctx context.Context, team string) error
+	TeamAddCoordinates(ctx context.Context, team, minifiedCoordinates string) string
+	TeamNextTask(ctx context.Context, team string, task int) string
+	GetTeamEvents(ctx context.Context, team string) ([]k8s.TeamEvent, error)
+	GetTeamSecret(ctx context.Context, team string) (map[string]string, error)
+	ReseedSecret(ctx context.Context, team string) (map[string]string, error)
+	PatchQuota(ctx context.Context, team string, spec k8s.QuotaSpec) (map[string]string, error)
+	ReviewToken(ctx context.Context, token string) (k8s.TokenIdentity, error)
+	ClusterRoleExists(ctx context.Context, name string) (bool, error)
+	MissingPermissions(ctx context.Context) ([]string, error)
+	GetScoreboard(ctx context.Context) (map[string]string, error)
+	IsDeploymentRunning(ctx context.Context, team, name string) (bool, error)
+	IsPodRunning(ctx context.Context, team, name string) (bool, error)
+	IsServiceRunning(ctx context.Context, team, name string) (bool, error)
+}
+
+var _ Provisioner = k8s.Client{}
@@ -0,0 +1,6 @@
+package api
+
+import "github.com/navikt/pleesah-havnesjef/internal/k8s/k8smock"
+
+// the handler tests drive the api with the mock, so it has to keep up with Provisioner
+var _ Provisioner = (*k8smock.Client)(nil)
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/navikt/pleesah-havnesjef/internal/audit"
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+)
+
+// Example: POST /api/v1/teams/purge?confirm=true
+// Deletes every team, spectators included, and lists the deleted spectators separately
+func (a *api) PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("confirm") != "true" {
+		writeJsonMessage(w, map[string]any{
+			"error": "purge must be confirmed with confirm=true",
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	teams, err := a.k8s.ListTeams(r.Context())
+	if err != nil {
+		a.logger(r.Context()).Error("failed listing teams", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed listing teams",
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	spectators, err := a.k8s.ListSpectators(r.Context())
+	if err != nil {
+		a.logger(r.Context()).Error("failed listing spectators", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed listing spectators",
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	failed := map[string]string{}
+	deleted := a.purgeTeams(r, teams, failed)
+	deletedSpectators := a.purgeTeams(r, spectators, failed)
+
+	a.logger(r.Context()).Info("Purged teams", "deleted", len(deleted), "spectators", len(deletedSpectators), "failed", len(failed))
+
+	statusCode := http.StatusOK
+	if len(failed) > 0 {
+		statusCode = http.StatusMultiStatus
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"deleted":    deleted,
+		"spectators": deletedSpectators,
+		"failed":     failed,
+	}, statusCode)
+}
+
+// purgeTeams deletes teams, returning the deleted ones and adding why the others failed to failed
+func (a *api) purgeTeams(r *http.Request, teams []k8s.Team, failed map[string]string) []string {
+	deleted := []string{}
+	for _, team := range teams {
+		err := a.k8s.DeleteTeam(r.Context(), team.Name)
+		a.audit(r, audit.DELETE, team.Name, "", err)
+		if err != nil {
+			a.logger(r.Context()).Error("failed deleting team", "error", err, "team", team.Name)
+			failed[team.Name] = err.Error()
+			continue
+		}
+
+		deleted = append(deleted, team.Name)
+	}
+
+	return deleted
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+)
+
+// Example: GET /readyz
+// Not ready until the ClusterRole players are bound to exists
+func (a *api) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	exists, err := a.k8s.ClusterRoleExists(r.Context(), k8s.PLAYER_ROLE)
+	if err != nil {
+		a.logger(r.Context()).Error("failed checking ClusterRole", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"ready": false,
+			"error": "failed checking ClusterRole",
+		}, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if !exists {
+		writeJsonMessage(w, map[string]any{
+			"ready": false,
+			"error": k8s.ErrNotSetUp.Error(),
+		}, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"ready": true,
+	}, http.StatusOK)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Example: GET /api/v1/scoreboard
+func (a *api) ScoreboardHandler(w http.ResponseWriter, r *http.Request) {
+	scoreboard, err := a.k8s.GetScoreboard(r.Context())
+	if err != nil {
+		if errors.Is(err, k8s.ErrNoScoreboard) || k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "scoreboard was not found",
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		a.logger(r.Context()).Error("failed fetching scoreboard", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed fetching scoreboard",
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"scoreboard": scoreboard,
+	}, http.StatusOK)
+}
@@ -0,0 +1,24 @@
+package api
+
+import "net/http"
+
+// Example: GET /api/v1/slots
+func (a *api) SlotsHandler(w http.ResponseWriter, r *http.Request) {
+	slots, err := a.k8s.GetTeamSlots(r.Context())
+	if err != nil {
+		a.logger(r.Context()).Error("failed counting team slots", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed counting team slots",
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"max":       slots.Max,
+		"used":      slots.Used,
+		"remaining": slots.Remaining,
+		"full":      slots.Full,
+		"unlimited": slots.Unlimited,
+	}, http.StatusOK)
+}
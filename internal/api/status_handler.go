@@ -7,9 +7,9 @@ import (
 
 // Example: GET /api/v1/{team}/status/{deployment|pod|service}/?name={string}
 func (a *api) teamResourceStatus(w http.ResponseWriter, r *http.Request) {
-	team := r.PathValue("team")
+	team := normalizeTeamName(r.PathValue("team"))
 	resource := r.PathValue("resource")
-	log := a.log.With("team", team, "resource", resource)
+	log := a.logger(r.Context()).With("team", team, "resource", resource)
 
 	if !slices.Contains([]string{"deployment", "pod", "service"}, resource) {
 		log.Error("resource is not valid")
@@ -42,7 +42,7 @@ func (a *api) teamResourceStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		a.log.Error("failed checking status", "error", err, "team", team, "name", name, "resources", resource)
+		a.logger(r.Context()).Error("failed checking status", "error", err, "team", team, "name", name, "resources", resource)
 		writeJsonMessage(w, map[string]any{
 			"error":    err,
 			"resource": resource,
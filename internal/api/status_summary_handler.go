@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+)
+
+type statusCheck struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type statusSummary struct {
+	Healthy     bool        `json:"healthy"`
+	APIServer   statusCheck `json:"apiServer"`
+	ClusterRole statusCheck `json:"clusterRole"`
+	Permissions statusCheck `json:"permissions"`
+	Missing     []string    `json:"missingPermissions,omitempty"`
+	Teams       int         `json:"teams"`
+}
+
+// Example: GET /api/v1/status
+// Sums up whether havnesjef can set up teams, for event operators
+func (a *api) StatusSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	log := a.logger(r.Context())
+	var summary statusSummary
+
+	// the ClusterRole lookup doubles as the check that the API server answers, like in /readyz
+	exists, err := a.k8s.ClusterRoleExists(r.Context(), k8s.PLAYER_ROLE)
+	if err != nil {
+		log.Error("failed checking ClusterRole", "error", err)
+		summary.APIServer.Error = "failed reaching the API server"
+		summary.ClusterRole.Error = "failed checking ClusterRole"
+	} else {
+		summary.APIServer.OK = true
+		summary.ClusterRole.OK = exists
+		if !exists {
+			summary.ClusterRole.Error = k8s.ErrNotSetUp.Error()
+		}
+	}
+
+	missing, err := a.k8s.MissingPermissions(r.Context())
+	switch {
+	case err != nil:
+		log.Error("failed checking permissions", "error", err)
+		summary.Permissions.Error = "failed checking permissions"
+	case len(missing) > 0:
+		summary.Permissions.Error = "havnesjef is missing permissions needed for setting up teams"
+		summary.Missing = missing
+	default:
+		summary.Permissions.OK = true
+	}
+
+	teams, err := a.k8s.ListTeams(r.Context())
+	if err != nil {
+		log.Error("failed listing teams", "error", err)
+	}
+	summary.Teams = len(teams)
+
+	summary.Healthy = summary.APIServer.OK && summary.ClusterRole.OK && summary.Permissions.OK
+	statusCode := http.StatusOK
+	if !summary.Healthy {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(summary)
+}
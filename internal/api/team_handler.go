@@ -2,32 +2,68 @@ package api
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/audit"
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 func (a *api) TeamHandler() http.Handler {
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{team}", a.teamDetails)
 	mux.HandleFunc("POST /{team}/create", a.teamCreate)
+	mux.HandleFunc("POST /{team}/renew", a.teamRenew)
+	mux.HandleFunc("POST /{team}/spectator", a.teamCreateSpectator)
 	mux.HandleFunc("POST /{team}/next-task", a.teamNextTask)
 	mux.HandleFunc("PUT /{team}/coordinates", a.teamAddCoordinates)
 	mux.HandleFunc("GET /{team}/status/{resource}", a.teamResourceStatus)
+	mux.HandleFunc("GET /{team}/events", a.teamEvents)
+	mux.HandleFunc("GET /{team}/token", a.teamExecToken)
+	mux.HandleFunc("GET /{team}/secret", a.requireAdmin(a.teamSecret))
+	mux.HandleFunc("POST /{team}/reseed", a.requireAdmin(a.teamReseed))
+	mux.HandleFunc("POST /{team}/quota", a.requireAdmin(a.teamQuota))
+	mux.HandleFunc("POST /{team}/delete", a.requireAdmin(a.teamDelete))
+	mux.HandleFunc("POST /{team}/rotate", a.requireAdmin(a.teamRotate))
 
 	return mux
 }
 
-// Example: POST /api/v1/team/{team}/create?hex={code}
+// Example: POST /api/v1/team/{team}/create?hex={code}&format={result}&auth={exec}&ttl={duration}
+// Responds with the kubeconfig, or a description of everything created when format=result.
+// With auth=exec the kubeconfig fetches tokens from havnesjef instead of carrying one, and only admins may get one for an existing team.
+// When JoinCode is set it must be given as code, and the team is named after it.
+// When RulesURL is set the rules must be accepted with acceptRules=true.
 func (a *api) teamCreate(w http.ResponseWriter, r *http.Request) {
-	team := r.PathValue("team")
-	log := a.log.With("team", team)
+	team := normalizeTeamName(r.PathValue("team"))
+
+	if err := validateTeam(team, a.config.MaxTeamNameLength); err != nil {
+		a.logger(r.Context()).Info("team is not valid", "team", team, "reason", err)
+		writeJsonMessage(w, map[string]any{
+			"error": err.Error(),
+			"code":  errorCode(err),
+		}, http.StatusBadRequest)
+
+		return
+	}
 
-	if !validateTeam(team) {
-		a.log.Error("team is not valid")
+	if blockedName(team, a.config.BlockedNames) {
+		a.logger(r.Context()).Info("team name is blocked", "team", team)
 		writeJsonMessage(w, map[string]any{
-			"error": "team is not valid",
+			"error": "team name is not allowed, please pick another one",
+			"team":  team,
+			"code":  CODE_NAME_BLOCKED,
 		}, http.StatusBadRequest)
 
 		return
@@ -35,31 +71,404 @@ func (a *api) teamCreate(w http.ResponseWriter, r *http.Request) {
 
 	hexcode := r.URL.Query().Get("hex")
 	if !validateHexcode(hexcode) {
-		a.log.Error("hex is not valid", "hex", hexcode)
+		a.logger(r.Context()).Error("hex is not valid", "hex", hexcode)
 		writeJsonMessage(w, map[string]any{
 			"error": "hex is not valid",
+			"code":  CODE_INVALID_HEX,
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	a.setupTeam(w, r, team, hexcode, k8s.PLAYER_ROLE)
+}
+
+// Example: POST /api/v1/team/{team}/spectator
+func (a *api) teamCreateSpectator(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+
+	if err := validateTeam(team, a.config.MaxTeamNameLength); err != nil {
+		a.logger(r.Context()).Info("team is not valid", "team", team, "reason", err)
+		writeJsonMessage(w, map[string]any{
+			"error": err.Error(),
+			"code":  errorCode(err),
 		}, http.StatusBadRequest)
 
 		return
 	}
 
-	k8sconfig, err := a.k8s.SetupTeam(r.Context(), team, hexcode)
+	if blockedName(team, a.config.BlockedNames) {
+		a.logger(r.Context()).Info("team name is blocked", "team", team)
+		writeJsonMessage(w, map[string]any{
+			"error": "team name is not allowed, please pick another one",
+			"team":  team,
+			"code":  CODE_NAME_BLOCKED,
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	a.setupTeam(w, r, team, "", k8s.SPECTATOR_ROLE)
+}
+
+// allowedToJoin checks that the rules are accepted and the join code matches, when RulesURL and JoinCode are set,
+// and responds with why when they are not
+func (a *api) allowedToJoin(w http.ResponseWriter, r *http.Request, team string) bool {
+	if a.config.RulesURL != "" {
+		if accepted, _ := strconv.ParseBool(r.URL.Query().Get("acceptRules")); !accepted {
+			writeJsonMessage(w, map[string]any{
+				"error": "read the rules, and accept them with acceptRules=true",
+				"rules": a.config.RulesURL,
+				"team":  team,
+				"code":  CODE_RULES_NOT_ACCEPTED,
+			}, http.StatusBadRequest)
+
+			return false
+		}
+	}
+
+	if a.config.JoinCode != "" {
+		code := r.URL.Query().Get("code")
+		if subtle.ConstantTimeCompare([]byte(code), []byte(a.config.JoinCode)) != 1 {
+			a.logger(r.Context()).Info("join code does not match", "team", team, "client_ip", a.clientIP(r))
+			writeJsonMessage(w, map[string]any{
+				"error": "join code is not valid",
+				"team":  team,
+				"code":  CODE_INVALID_JOIN_CODE,
+			}, http.StatusForbidden)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// setupTeam creates the team bound to role, and responds with the kubeconfig for it
+func (a *api) setupTeam(w http.ResponseWriter, r *http.Request, team, hexcode, role string) {
+	if !a.allowedToJoin(w, r, team) {
+		return
+	}
+
+	if a.config.JoinCode != "" {
+		joined := joinedTeamName(team, r.URL.Query().Get("code"))
+		if len(joined) > a.config.MaxTeamNameLength {
+			writeJsonMessage(w, map[string]any{
+				"error": fmt.Sprintf("team name must be at most %d characters", a.config.MaxTeamNameLength-(len(joined)-len(team))),
+				"team":  team,
+				"code":  CODE_NAME_TOO_LONG,
+			}, http.StatusBadRequest)
+
+			return
+		}
+
+		team = joined
+	}
+
+	log := a.logger(r.Context()).With("team", team, "role", role)
+
+	var ttl time.Duration
+	if ttlString := r.URL.Query().Get("ttl"); ttlString != "" {
+		var err error
+		ttl, err = time.ParseDuration(ttlString)
+		if err != nil || ttl <= 0 {
+			writeJsonMessage(w, map[string]any{
+				"error": "ttl is not a duration, e.g. 1h or 8h",
+				"team":  team,
+				"code":  k8s.CODE_INVALID_TTL,
+			}, http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	exec := r.URL.Query().Get("auth") == "exec"
+
+	var result k8s.TeamResult
+	var err error
+	if exec {
+		// a new exec key locks out whoever holds the old one, so only admins may take over an existing team
+		result, err = a.k8s.SetupTeamExec(r.Context(), team, hexcode, role, a.isAdmin(r))
+	} else {
+		result, err = a.k8s.SetupTeamResult(r.Context(), team, hexcode, role, ttl)
+	}
+	a.audit(r, audit.CREATE, team, role, err)
 	if err != nil {
+		a.writeSetupError(w, r, team, err)
+		return
+	}
+
+	log.Info("Created new team")
+	a.config.Notifier.TeamCreated(log, team, role)
+
+	if r.URL.Query().Get("format") == "result" {
+		a.warnInsecureKubeconfig(w)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		_ = json.NewEncoder(w).Encode(result)
+
+		return
+	}
+
+	a.writeKubeconfig(w, r, team, result.Kubeconfig)
+}
+
+// writeSetupError responds with a status code matching why setting up the team failed
+func (a *api) writeSetupError(w http.ResponseWriter, r *http.Request, team string, err error) {
+	log := a.logger(r.Context()).With("team", team)
+
+	var setupErr *k8s.SetupError
+	if !errors.As(err, &setupErr) {
 		log.Error("failed creating team", "error", err)
 		writeJsonMessage(w, map[string]any{
-			"error":   "failed creating team",
-			"team":    team,
-			"hexcode": hexcode,
+			"error": "failed creating team",
+			"team":  team,
+			"code":  k8s.CODE_INTERNAL,
 		}, http.StatusInternalServerError)
 
 		return
 	}
 
-	log.Info("Created new team")
+	log = log.With("stage", setupErr.Stage)
+	switch {
+	case setupErr.IsValidation():
+		log.Info("refused creating team", "reason", err)
+		writeJsonMessage(w, map[string]any{
+			"error": setupErr.Err.Error(),
+			"team":  team,
+			"code":  setupErr.Code(),
+		}, http.StatusBadRequest)
+	case setupErr.IsConflict():
+		log.Info("refused creating team", "reason", err)
+		writeJsonMessage(w, map[string]any{
+			"error": setupErr.Err.Error(),
+			"team":  team,
+			"code":  setupErr.Code(),
+		}, http.StatusConflict)
+	case errors.Is(err, k8s.ErrNotSetUp):
+		log.Warn("refused creating team", "reason", err)
+		writeJsonMessage(w, map[string]any{
+			"error": k8s.ErrNotSetUp.Error(),
+			"team":  team,
+			"code":  setupErr.Code(),
+		}, http.StatusServiceUnavailable)
+	case setupErr.IsTransient():
+		log.Warn("failed creating team, it may work to try again", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "team is not ready yet, try again shortly",
+			"team":  team,
+			"stage": setupErr.Stage,
+			"code":  setupErr.Code(),
+		}, http.StatusServiceUnavailable)
+	case errors.Is(err, k8s.ErrEmptyToken):
+		log.Error("failed creating team", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": k8s.ErrEmptyToken.Error(),
+			"team":  team,
+			"stage": setupErr.Stage,
+			"code":  setupErr.Code(),
+		}, http.StatusInternalServerError)
+	default:
+		log.Error("failed creating team", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed creating team",
+			"team":  team,
+			"stage": setupErr.Stage,
+			"code":  setupErr.Code(),
+		}, http.StatusInternalServerError)
+	}
+}
+
+// Example: POST /api/v1/team/{team}/renew?auth={exec}
+// Like create, it needs code and acceptRules when JoinCode and RulesURL are set.
+func (a *api) teamRenew(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	if !a.allowedToJoin(w, r, team) {
+		return
+	}
+
+	// the exec key mints tokens until it is replaced, so only admins get a new one for an existing team
+	if r.URL.Query().Get("auth") == "exec" {
+		a.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+			k8sconfig, err := a.k8s.ExecKubeconfig(r.Context(), team)
+			a.audit(r, audit.RENEW, team, "", err)
+			if err != nil {
+				a.writeExecKubeconfigError(w, r, team, err)
+				return
+			}
+
+			a.writeKubeconfig(w, r, team, k8sconfig)
+		})(w, r)
+
+		return
+	}
+
+	k8sconfig, err := a.k8s.RenewToken(r.Context(), team)
+	a.audit(r, audit.RENEW, team, "", err)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		if errors.Is(err, k8s.ErrTokenStillValid) {
+			writeJsonMessage(w, map[string]any{
+				"error": err.Error(),
+				"team":  team,
+				"code":  k8s.CODE_TOKEN_VALID,
+			}, http.StatusConflict)
+
+			return
+		}
+
+		message := "failed renewing token"
+		if errors.Is(err, k8s.ErrEmptyToken) {
+			message = k8s.ErrEmptyToken.Error()
+		}
+
+		log.Error("failed renewing token", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": message,
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	log.Info("Renewed token")
+	a.writeKubeconfig(w, r, team, k8sconfig)
+}
+
+// Example: GET /api/v1/team/{team}
+func (a *api) teamDetails(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+
+	details, err := a.k8s.GetTeam(r.Context(), team)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		a.logger(r.Context()).Error("failed fetching team", "error", err, "team", team)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed fetching team",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_ = json.NewEncoder(w).Encode(details)
+}
+
+// Example: GET /api/v1/team/{team}/events
+func (a *api) teamEvents(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+
+	events, err := a.k8s.GetTeamEvents(r.Context(), team)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		a.logger(r.Context()).Error("failed fetching events", "error", err, "team", team)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed fetching events",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"team":   team,
+		"events": events,
+	}, http.StatusOK)
+}
+
+// Example: GET /api/v1/team/{team}/token
+// Called by kubectl through the exec kubeconfig, with the exec key as bearer token
+func (a *api) teamExecToken(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !a.k8s.ValidExecKey(r.Context(), team, key) {
+		writeJsonMessage(w, map[string]any{
+			"error": "unauthorized",
+		}, http.StatusUnauthorized)
+
+		return
+	}
+
+	credential, err := a.k8s.ExecCredential(r.Context(), team)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		log.Error("failed creating token", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed creating token",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_ = json.NewEncoder(w).Encode(credential)
+}
+
+func (a *api) writeExecKubeconfigError(w http.ResponseWriter, r *http.Request, team string, err error) {
+	switch {
+	case errors.Is(err, k8s.ErrExecAuthDisabled):
+		writeJsonMessage(w, map[string]any{
+			"error": err.Error(),
+		}, http.StatusBadRequest)
+	case k8serrors.IsNotFound(err):
+		writeJsonMessage(w, map[string]any{
+			"error": "team was not found",
+			"team":  team,
+		}, http.StatusNotFound)
+	default:
+		a.logger(r.Context()).Error("failed creating exec kubeconfig", "error", err, "team", team)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed creating exec kubeconfig",
+			"team":  team,
+		}, http.StatusInternalServerError)
+	}
+}
 
+// writeKubeconfig responds with the kubeconfig minified, ending with a newline
+func (a *api) writeKubeconfig(w http.ResponseWriter, r *http.Request, team, k8sconfig string) {
 	buffer := new(bytes.Buffer)
-	if err = json.Compact(buffer, []byte(k8sconfig)); err != nil {
-		a.log.Error("failed minifying kubeconfig", "error", err)
+	if err := json.Compact(buffer, []byte(k8sconfig)); err != nil {
+		a.logger(r.Context()).Error("failed minifying kubeconfig", "error", err)
 		writeJsonMessage(w, map[string]any{
 			"error": "",
 			"team":  team,
@@ -70,11 +479,90 @@ func (a *api) teamCreate(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+	a.warnInsecureKubeconfig(w)
+	buffer.WriteString("\n")
 	_, _ = w.Write(buffer.Bytes())
 }
 
-func validateTeam(team string) bool {
-	return regexp.MustCompile(`^[a-zA-Z0-9-]{2,63}$`).Match([]byte(team))
+// warnInsecureKubeconfig tells clients in a Warning header when kubeconfigs skip TLS verification
+func (a *api) warnInsecureKubeconfig(w http.ResponseWriter) {
+	if a.config.InsecureKubeconfig {
+		w.Header().Set("Warning", `299 havnesjef "kubeconfig skips TLS verification"`)
+	}
+}
+
+// joinedTeamName suffixes team with a short hash of the join code, so only those with the code can claim names
+func joinedTeamName(team, code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return team + "-" + hex.EncodeToString(sum[:])[:6]
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// normalizeTeamName trims and lowercases the team name, and replaces whitespace with hyphens.
+func normalizeTeamName(team string) string {
+	team = strings.ToLower(strings.TrimSpace(team))
+	return whitespace.ReplaceAllString(team, "-")
+}
+
+// Codes for requests refused before setup starts, next to those from k8s.SetupError
+const (
+	CODE_NAME_TOO_LONG      = "name_too_long"
+	CODE_NAME_BLOCKED       = "name_blocked"
+	CODE_INVALID_HEX        = "invalid_hex"
+	CODE_INVALID_JOIN_CODE  = "invalid_join_code"
+	CODE_RULES_NOT_ACCEPTED = "rules_not_accepted"
+)
+
+// codedError carries a code for the JSON error body along with the message
+type codedError struct {
+	Code    string
+	Message string
+}
+
+func (e *codedError) Error() string {
+	return e.Message
+}
+
+// errorCode returns the code of a codedError, or k8s.CODE_INTERNAL for anything else
+func errorCode(err error) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+
+	return k8s.CODE_INTERNAL
+}
+
+var teamCharacters = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// validateTeam checks the team name after normalizeTeamName
+func validateTeam(team string, maxLength int) error {
+	if len(team) > maxLength {
+		return &codedError{Code: CODE_NAME_TOO_LONG, Message: fmt.Sprintf("team must be between 2 and %d characters", maxLength)}
+	}
+
+	if len(team) < 2 {
+		return &codedError{Code: k8s.CODE_INVALID_NAME, Message: fmt.Sprintf("team must be between 2 and %d characters", maxLength)}
+	}
+
+	if !teamCharacters.MatchString(team) {
+		return &codedError{Code: k8s.CODE_INVALID_NAME, Message: "team can only contain a-z, 0-9 and -, and must start and end with a letter or digit"}
+	}
+
+	return nil
+}
+
+// blockedName reports whether team matches any of the names or glob patterns, ignoring case
+func blockedName(team string, patterns []string) bool {
+	team = strings.ToLower(team)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), team); matched {
+			return true
+		}
+	}
+
+	return false
 }
 
 func validateHexcode(hex string) bool {
@@ -84,8 +572,8 @@ func validateHexcode(hex string) bool {
 // Example: PUT /api/v1/team/{team}/coordinates
 // Payload: {x: 0, y: 1}
 func (a *api) teamAddCoordinates(w http.ResponseWriter, r *http.Request) {
-	team := r.PathValue("team")
-	log := a.log.With("team", team)
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
 	type Coordinates struct {
 		X int
 		Y int
@@ -93,6 +581,15 @@ func (a *api) teamAddCoordinates(w http.ResponseWriter, r *http.Request) {
 
 	var coordinates Coordinates
 	if err := json.NewDecoder(r.Body).Decode(&coordinates); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJsonMessage(w, map[string]any{
+				"error": fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit),
+			}, http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
 		log.Error("failed parsing body", "error", err)
 		writeJsonMessage(w, map[string]any{
 			"error": "failed parsing body",
@@ -122,12 +619,12 @@ func (a *api) teamAddCoordinates(w http.ResponseWriter, r *http.Request) {
 
 // Example: POST /api/v1/team/{team}/next-task?task=int
 func (a *api) teamNextTask(w http.ResponseWriter, r *http.Request) {
-	team := r.PathValue("team")
-	log := a.log.With("team", team)
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
 
 	taskString := r.URL.Query().Get("task")
 	if taskString == "" {
-		a.log.Error("missing task query parameter")
+		a.logger(r.Context()).Error("missing task query parameter")
 		writeJsonMessage(w, map[string]any{
 			"error": "missing task query parameter",
 		}, http.StatusBadRequest)
@@ -137,7 +634,7 @@ func (a *api) teamNextTask(w http.ResponseWriter, r *http.Request) {
 
 	taskInt, err := strconv.Atoi(taskString)
 	if err != nil {
-		a.log.Error("task is not int", "error", err, "task", taskString)
+		a.logger(r.Context()).Error("task is not int", "error", err, "task", taskString)
 
 		writeJsonMessage(w, map[string]any{
 			"error": "can not parse task as int",
@@ -163,3 +660,237 @@ func (a *api) teamNextTask(w http.ResponseWriter, r *http.Request) {
 		"team":    team,
 	}, http.StatusOK)
 }
+
+// Example: GET /api/v1/team/{team}/secret
+func (a *api) teamSecret(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	secret, err := a.k8s.GetTeamSecret(r.Context(), team)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team or secret was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		log.Error("failed fetching secret", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed fetching secret",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"team":   team,
+		"secret": secret,
+	}, http.StatusOK)
+}
+
+// Example: POST /api/v1/team/{team}/reseed
+func (a *api) teamReseed(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	secret, err := a.k8s.ReseedSecret(r.Context(), team)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		if errors.Is(err, k8s.ErrNoSeedSecrets) {
+			writeJsonMessage(w, map[string]any{
+				"error": err.Error(),
+				"team":  team,
+			}, http.StatusConflict)
+
+			return
+		}
+
+		log.Error("failed reseeding secret", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed reseeding secret",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	log.Info("Reseeded secret")
+	writeJsonMessage(w, map[string]any{
+		"team":   team,
+		"secret": secret,
+	}, http.StatusOK)
+}
+
+// Example: POST /api/v1/team/{team}/quota
+// Payload: {"cpu": "2", "memory": "4Gi", "pods": "20"}
+func (a *api) teamQuota(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	var spec k8s.QuotaSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJsonMessage(w, map[string]any{
+				"error": fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit),
+			}, http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		writeJsonMessage(w, map[string]any{
+			"error": "failed parsing body",
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	resources, err := spec.ResourceList()
+	if err != nil {
+		writeJsonMessage(w, map[string]any{
+			"error": err.Error(),
+			"team":  team,
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	if len(resources) == 0 {
+		writeJsonMessage(w, map[string]any{
+			"error": "at least one of cpu, memory and pods must be set",
+			"team":  team,
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	quota, err := a.k8s.PatchQuota(r.Context(), team, spec)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		log.Error("failed updating quota", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed updating quota",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	log.Info("Updated quota", "quota", quota)
+	writeJsonMessage(w, map[string]any{
+		"team":  team,
+		"quota": quota,
+	}, http.StatusOK)
+}
+
+// Example: POST /api/v1/team/{team}/delete?confirm={team}
+// The team name must be typed again in confirm, so a team is not deleted by accident
+func (a *api) teamDelete(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	if normalizeTeamName(r.URL.Query().Get("confirm")) != team {
+		writeJsonMessage(w, map[string]any{
+			"error": "confirm must be the name of the team",
+			"team":  team,
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	err := a.k8s.DeleteTeam(r.Context(), team)
+	a.audit(r, audit.DELETE, team, "", err)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		if errors.Is(err, k8s.ErrTeamNotManaged) {
+			writeJsonMessage(w, map[string]any{
+				"error": err.Error(),
+				"team":  team,
+			}, http.StatusConflict)
+
+			return
+		}
+
+		log.Error("failed deleting team", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed deleting team",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	log.Info("Deleted team")
+	writeJsonMessage(w, map[string]any{
+		"message": "Team was deleted",
+		"team":    team,
+	}, http.StatusOK)
+}
+
+// Example: POST /api/v1/team/{team}/rotate
+// Recreates the team service account, so every token handed out earlier, leaked or not, stops working
+func (a *api) teamRotate(w http.ResponseWriter, r *http.Request) {
+	team := normalizeTeamName(r.PathValue("team"))
+	log := a.logger(r.Context()).With("team", team)
+
+	k8sconfig, err := a.k8s.RotateServiceAccount(r.Context(), team)
+	a.audit(r, audit.ROTATE, team, "", err)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			writeJsonMessage(w, map[string]any{
+				"error": "team was not found",
+				"team":  team,
+			}, http.StatusNotFound)
+
+			return
+		}
+
+		if errors.Is(err, k8s.ErrTeamNotManaged) {
+			writeJsonMessage(w, map[string]any{
+				"error": err.Error(),
+				"team":  team,
+			}, http.StatusConflict)
+
+			return
+		}
+
+		log.Error("failed rotating service account", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed rotating service account",
+			"team":  team,
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	log.Info("Rotated token")
+	a.writeKubeconfig(w, r, team, k8sconfig)
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	"github.com/navikt/pleesah-havnesjef/internal/k8s/k8smock"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newTestAPI(client Provisioner, config Config) api {
+	config.MaxBodyBytes = 64 << 10
+	config.MaxTeamNameLength = 40
+	return New(client, slog.New(slog.DiscardHandler), config)
+}
+
+// serve sends the request through the whole middleware chain, and decodes JSON error bodies
+func serve(t *testing.T, a api, method, target string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	a.server.Handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+
+	body := map[string]any{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &body)
+	return recorder, body
+}
+
+func TestTeamCreate(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{})
+
+	recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/Team-A/create?hex=%23ff0000")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+	}
+
+	if body["kind"] != "Config" {
+		t.Errorf("expected a kubeconfig, got %s", recorder.Body)
+	}
+
+	recorder, body = serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000&format=result")
+	if recorder.Code != http.StatusOK || body["team"] != "team-a" {
+		t.Errorf("expected the result for team-a, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestTeamCreateInvalidName(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{})
+
+	tests := []struct {
+		team string
+		code string
+	}{
+		{"a", k8s.CODE_INVALID_NAME},
+		{"team_a", k8s.CODE_INVALID_NAME},
+		{"-team", k8s.CODE_INVALID_NAME},
+		{"team-with-a-name-that-is-much-too-long-to-use", CODE_NAME_TOO_LONG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.team, func(t *testing.T) {
+			recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/"+tt.team+"/create?hex=%23ff0000")
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body)
+			}
+
+			if body["code"] != tt.code {
+				t.Errorf("code = %v, want %s", body["code"], tt.code)
+			}
+		})
+	}
+}
+
+func TestTeamCreateInvalidHex(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{})
+
+	recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=red")
+	if recorder.Code != http.StatusBadRequest || body["code"] != CODE_INVALID_HEX {
+		t.Errorf("expected %s, got %d: %s", CODE_INVALID_HEX, recorder.Code, recorder.Body)
+	}
+}
+
+func TestTeamCreateExistingTeam(t *testing.T) {
+	client := k8smock.New()
+	client.Err = &k8s.SetupError{
+		Stage: k8s.STAGE_NAMESPACE,
+		Team:  "team-a",
+		Err:   k8serrors.NewAlreadyExists(schema.GroupResource{Resource: "namespaces"}, "team-a"),
+	}
+	a := newTestAPI(client, Config{})
+
+	recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000")
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusConflict, recorder.Body)
+	}
+
+	if body["code"] != k8s.CODE_TEAM_EXISTS || body["team"] != "team-a" {
+		t.Errorf("unexpected body: %s", recorder.Body)
+	}
+}
+
+func TestTeamCreateExecExistingTeam(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{AdminToken: "admin"})
+
+	if recorder, _ := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000&auth=exec"); recorder.Code != http.StatusOK {
+		t.Fatalf("creating team: status = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000&auth=exec")
+	if recorder.Code != http.StatusConflict || body["code"] != k8s.CODE_TEAM_EXISTS {
+		t.Errorf("expected %s without the admin token, got %d: %s", k8s.CODE_TEAM_EXISTS, recorder.Code, recorder.Body)
+	}
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000&auth=exec", nil)
+	request.Header.Set("Authorization", "Bearer admin")
+	a.server.Handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected a kubeconfig with the admin token, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestTeamRenew(t *testing.T) {
+	a := newTestAPI(k8smock.New(), Config{})
+
+	recorder, _ := serve(t, a, http.MethodPost, "/api/v1/team/team-a/renew")
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("renewing a missing team: status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+
+	if recorder, _ := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000"); recorder.Code != http.StatusOK {
+		t.Fatalf("creating team: status = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/team-a/renew")
+	if recorder.Code != http.StatusOK || body["kind"] != "Config" {
+		t.Errorf("expected a kubeconfig, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestTeamRenewNeedsJoinCode(t *testing.T) {
+	client := k8smock.New()
+	a := newTestAPI(client, Config{JoinCode: "hemmelig"})
+
+	recorder, body := serve(t, a, http.MethodPost, "/api/v1/team/team-a/create?hex=%23ff0000&code=hemmelig&format=result")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("creating team: status = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	team := body["team"].(string)
+	recorder, body = serve(t, a, http.MethodPost, "/api/v1/team/"+team+"/renew")
+	if recorder.Code != http.StatusForbidden || body["code"] != CODE_INVALID_JOIN_CODE {
+		t.Errorf("expected %s without the code, got %d: %s", CODE_INVALID_JOIN_CODE, recorder.Code, recorder.Body)
+	}
+
+	recorder, _ = serve(t, a, http.MethodPost, "/api/v1/team/"+team+"/renew?code=hemmelig")
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected a kubeconfig with the code, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestNormalizeTeamName(t *testing.T) {
+	tests := []struct {
+		name string
+		team string
+		want string
+	}{
+		{"unchanged", "team-a", "team-a"},
+		{"trailing spaces", "team-a  ", "team-a"},
+		{"leading tab", "\tteam-a", "team-a"},
+		{"uppercase", "Team-A", "team-a"},
+		{"inner space", "team a", "team-a"},
+		{"inner whitespace run", "team \t a", "team-a"},
+		{"newline", "team\na\n", "team-a"},
+		{"only whitespace", " \t ", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTeamName(tt.team); got != tt.want {
+				t.Errorf("normalizeTeamName(%q) = %q, want %q", tt.team, got, tt.want)
+			}
+		})
+	}
+}
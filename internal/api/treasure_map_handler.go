@@ -3,13 +3,33 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
 )
 
-// Example: GET /api/v1/teams
+// Example: GET /api/v1/teams?q={string}&limit={int}&continue={token}
+// Without limit every team is returned as a list, with limit a page of teams is returned along with
+// the continue token for the next page. q only keeps teams with names containing it, ignoring case.
 func (a *api) TreasureMapHandler(w http.ResponseWriter, r *http.Request) {
-	teams, err := a.k8s.ListTeams(r.Context())
+	var limit int64
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.ParseInt(limitString, 10, 64)
+		if err != nil || limit < 1 {
+			writeJsonMessage(w, map[string]any{
+				"error": "limit must be a positive int",
+				"limit": limitString,
+			}, http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	teams, continueToken, err := a.k8s.ListTeamsPage(r.Context(), limit, r.URL.Query().Get("continue"))
 	if err != nil {
-		a.log.Error("failed listing teams", "error", err)
+		a.logger(r.Context()).Error("failed listing teams", "error", err)
 		writeJsonMessage(w, map[string]any{
 			"error": "failed listing teams",
 		}, http.StatusInternalServerError)
@@ -17,7 +37,32 @@ func (a *api) TreasureMapHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if q := r.URL.Query().Get("q"); q != "" {
+		teams = filterTeams(teams, q)
+	}
+
+	if limit > 0 {
+		writeJsonMessage(w, map[string]any{
+			"teams":    teams,
+			"continue": continueToken,
+		}, http.StatusOK)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	_ = json.NewEncoder(w).Encode(teams)
 }
+
+func filterTeams(teams []k8s.Team, q string) []k8s.Team {
+	q = strings.ToLower(q)
+	filtered := []k8s.Team{}
+	for _, team := range teams {
+		if strings.Contains(strings.ToLower(team.Name), q) {
+			filtered = append(filtered, team)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/navikt/pleesah-havnesjef/internal/version"
+)
+
+// Example: GET /api/v1/version
+func (a *api) VersionHandler(w http.ResponseWriter, r *http.Request) {
+	info := version.Get()
+	writeJsonMessage(w, map[string]any{
+		"version": info.Version,
+		"commit":  info.Commit,
+		"date":    info.Date,
+	}, http.StatusOK)
+}
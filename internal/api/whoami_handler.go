@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Example: POST /api/v1/whoami
+// Payload: {"token": "..."}
+// Tells which team a token belongs to
+func (a *api) WhoamiHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJsonMessage(w, map[string]any{
+				"error": fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit),
+			}, http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		writeJsonMessage(w, map[string]any{
+			"error": "failed parsing body",
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	if body.Token == "" {
+		writeJsonMessage(w, map[string]any{
+			"error": "token can not be empty",
+		}, http.StatusBadRequest)
+
+		return
+	}
+
+	identity, err := a.k8s.ReviewToken(r.Context(), body.Token)
+	if err != nil {
+		a.logger(r.Context()).Error("failed reviewing token", "error", err)
+		writeJsonMessage(w, map[string]any{
+			"error": "failed reviewing token",
+		}, http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJsonMessage(w, map[string]any{
+		"authenticated": identity.Authenticated,
+		"username":      identity.Username,
+		"team":          identity.Namespace,
+		"error":         identity.Error,
+	}, http.StatusOK)
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/request"
+)
+
+const (
+	CREATE = "create"
+	RENEW  = "renew"
+	ROTATE = "rotate"
+	DELETE = "delete"
+)
+
+// Logger appends one JSON record per team operation. A nil Logger records nothing.
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"`
+	Team      string    `json:"team"`
+	Role      string    `json:"role,omitempty"`
+	ActorIP   string    `json:"actor_ip"`
+	RequestID string    `json:"request_id,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func New(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// Open returns a Logger writing to stdout or appending to the file at path, or nil when path is empty
+func Open(path string) (*Logger, error) {
+	switch path {
+	case "":
+		return nil, nil
+	case "stdout":
+		return New(os.Stdout), nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- path is operator supplied configuration
+	if err != nil {
+		return nil, err
+	}
+
+	return New(file), nil
+}
+
+// Record writes what happened to team, with result failure when err is set
+func (l *Logger) Record(ctx context.Context, action, team, role, actorIP string, err error) error {
+	if l == nil {
+		return nil
+	}
+
+	record := Record{
+		Timestamp: time.Now().UTC(),
+		Action:    action,
+		Team:      team,
+		Role:      role,
+		ActorIP:   actorIP,
+		RequestID: request.ID(ctx),
+		Result:    "success",
+	}
+
+	if err != nil {
+		record.Result = "failure"
+		record.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return json.NewEncoder(l.w).Encode(record)
+}
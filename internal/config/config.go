@@ -0,0 +1,655 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/api"
+	"github.com/navikt/pleesah-havnesjef/internal/audit"
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	"github.com/navikt/pleesah-havnesjef/internal/notify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Config holds every tunable of havnesjef. It is read from the YAML file in CONFIG when set,
+// and each value can be overridden by its environment variable.
+type Config struct {
+	Endpoint                     string            `json:"endpoint"`
+	CA                           string            `json:"ca"`
+	LogFormat                    string            `json:"logFormat"`
+	LogLevel                     string            `json:"logLevel"`
+	AdminToken                   string            `json:"adminToken"`
+	JoinCode                     string            `json:"joinCode"`
+	RulesURL                     string            `json:"rulesURL"`
+	MaxBodyBytes                 int64             `json:"maxBodyBytes"`
+	TLSCert                      string            `json:"tlsCert"`
+	TLSKey                       string            `json:"tlsKey"`
+	SeedSpec                     string            `json:"seedSpec"`
+	SeedSecrets                  bool              `json:"seedSecrets"`
+	SecretProvider               string            `json:"secretProvider"`
+	CoordinatesNamespace         string            `json:"coordinatesNamespace"`
+	CoordinatesConfigMap         string            `json:"coordinatesConfigMap"`
+	Instructions                 string            `json:"instructions"`
+	InstructionsConfigMap        string            `json:"instructionsConfigMap"`
+	PostCreate                   string            `json:"postCreate"`
+	PostCreateFatal              bool              `json:"postCreateFatal"`
+	KubeconfigTemplate           string            `json:"kubeconfigTemplate"`
+	KubeconfigContext            string            `json:"kubeconfigContext"`
+	InsecureKubeconfig           bool              `json:"insecureKubeconfig"`
+	SetupAttempts                int               `json:"setupAttempts"`
+	TokenAudiences               []string          `json:"tokenAudiences"`
+	MaxTeams                     int               `json:"maxTeams"`
+	EventName                    string            `json:"eventName"`
+	BindMode                     string            `json:"bindMode"`
+	NamespaceLabels              map[string]string `json:"namespaceLabels"`
+	NamespaceAnnotations         map[string]string `json:"namespaceAnnotations"`
+	PlayerRoleRules              string            `json:"playerRoleRules"`
+	TeamRoleRules                string            `json:"teamRoleRules"`
+	TeamRoleOnly                 bool              `json:"teamRoleOnly"`
+	PriorityClass                string            `json:"priorityClass"`
+	AuditLog                     string            `json:"auditLog"`
+	NotifyWebhook                string            `json:"notifyWebhook"`
+	BlockedNames                 []string          `json:"blockedNames"`
+	TrustedProxies               []string          `json:"trustedProxies"`
+	MaxTeamNameLength            int               `json:"maxTeamNameLength"`
+	ScoreboardNamespace          string            `json:"scoreboardNamespace"`
+	ScoreboardConfigMap          string            `json:"scoreboardConfigMap"`
+	BasePath                     string            `json:"basePath"`
+	EnablePprof                  bool              `json:"enablePprof"`
+	ExecAuthURL                  string            `json:"execAuthURL"`
+	ExecAuthSecret               string            `json:"execAuthSecret"`
+	WaitReady                    metav1.Duration   `json:"waitReady"`
+	TokenRefreshWindow           metav1.Duration   `json:"tokenRefreshWindow"`
+	RequestTimeout               metav1.Duration   `json:"requestTimeout"`
+	MetricsInterval              metav1.Duration   `json:"metricsInterval"`
+	TokenTTL                     metav1.Duration   `json:"tokenTTL"`
+	MinTokenTTL                  metav1.Duration   `json:"minTokenTTL"`
+	MaxTokenTTL                  metav1.Duration   `json:"maxTokenTTL"`
+	AutomountServiceAccountToken *bool             `json:"automountServiceAccountToken"`
+	TokenSecretFallback          bool              `json:"tokenSecretFallback"`
+	WriteTokenSecret             bool              `json:"writeTokenSecret"`
+	TokenSecretName              string            `json:"tokenSecretName"`
+	PullSecret                   string            `json:"pullSecret"`
+	PullSecretNamespace          string            `json:"pullSecretNamespace"`
+	IngressHost                  string            `json:"ingressHost"`
+	IngressClass                 string            `json:"ingressClass"`
+	IngressService               string            `json:"ingressService"`
+	IngressServicePort           int32             `json:"ingressServicePort"`
+	MeshInjection                string            `json:"meshInjection"`
+	MeshInjectionLabel           string            `json:"meshInjectionLabel"`
+}
+
+func defaults() Config {
+	k8sDefaults := k8s.DefaultConfig("", "")
+	return Config{
+		MaxBodyBytes:          64 << 10,
+		MaxTeamNameLength:     40,
+		SeedSecrets:           true,
+		SecretProvider:        k8s.SECRET_PROVIDER_COORDINATES,
+		CoordinatesNamespace:  "pleesah-system",
+		CoordinatesConfigMap:  "havnesjef-coordinates",
+		InstructionsConfigMap: "oppgave",
+		PostCreateFatal:       true,
+		RequestTimeout:        metav1.Duration{Duration: 30 * time.Second},
+		MetricsInterval:       metav1.Duration{Duration: 30 * time.Second},
+		KubeconfigContext:     k8sDefaults.ContextName,
+		SetupAttempts:         k8sDefaults.Attempts,
+		BindMode:              k8sDefaults.BindMode,
+		IngressService:        k8sDefaults.Ingress.ServiceName,
+		IngressServicePort:    k8sDefaults.Ingress.ServicePort,
+		TokenTTL:              metav1.Duration{Duration: k8sDefaults.TokenTTL},
+		MinTokenTTL:           metav1.Duration{Duration: k8sDefaults.MinTokenTTL},
+		MaxTokenTTL:           metav1.Duration{Duration: k8sDefaults.MaxTokenTTL},
+		MeshInjection:         "enabled",
+		MeshInjectionLabel:    "istio-injection",
+		PullSecretNamespace:   "pleesah-system",
+		TokenSecretName:       "havnesjef-token",
+	}
+}
+
+// LogValue lists every setting by its name in the config file, with secrets redacted
+func (c Config) LogValue() slog.Value {
+	for _, secret := range []*string{&c.AdminToken, &c.JoinCode, &c.ExecAuthSecret, &c.NotifyWebhook} {
+		if *secret != "" {
+			*secret = "[redacted]"
+		}
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(payload, &values); err != nil {
+		return slog.StringValue(err.Error())
+	}
+
+	attrs := make([]slog.Attr, 0, len(values))
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		attrs = append(attrs, slog.Any(key, values[key]))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Load reads the config file from CONFIG, applies environment variables on top, and validates the result.
+func Load() (Config, error) {
+	cfg := defaults()
+	if path := os.Getenv("CONFIG"); path != "" {
+		payload, err := os.ReadFile(path) // #nosec G304 -- path is operator supplied configuration
+		if err != nil {
+			return Config{}, err
+		}
+
+		if err := yaml.UnmarshalStrict(payload, &cfg); err != nil {
+			return Config{}, fmt.Errorf("failed parsing %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+
+	cfg.BasePath = strings.TrimSuffix(cfg.BasePath, "/")
+	return cfg, cfg.validate()
+}
+
+func (c *Config) applyEnv() error {
+	envString("ENDPOINT", &c.Endpoint)
+	envString("CA", &c.CA)
+	envString("LOG_FORMAT", &c.LogFormat)
+	envString("LOG_LEVEL", &c.LogLevel)
+	envString("ADMIN_TOKEN", &c.AdminToken)
+	envString("JOIN_CODE", &c.JoinCode)
+	envString("RULES_URL", &c.RulesURL)
+	envString("TLS_CERT", &c.TLSCert)
+	envString("TLS_KEY", &c.TLSKey)
+	envString("SEED_SPEC", &c.SeedSpec)
+	envString("SECRET_PROVIDER", &c.SecretProvider)
+	envString("COORDINATES_NAMESPACE", &c.CoordinatesNamespace)
+	envString("COORDINATES_CONFIGMAP", &c.CoordinatesConfigMap)
+	envString("INSTRUCTIONS", &c.Instructions)
+	envString("INSTRUCTIONS_CONFIGMAP", &c.InstructionsConfigMap)
+	envString("POST_CREATE", &c.PostCreate)
+	envString("KUBECONFIG_TEMPLATE", &c.KubeconfigTemplate)
+	envString("KUBECONFIG_CONTEXT", &c.KubeconfigContext)
+	envString("BIND_MODE", &c.BindMode)
+	envString("EVENT_NAME", &c.EventName)
+	envString("PLAYER_ROLE_RULES", &c.PlayerRoleRules)
+	envString("TEAM_ROLE_RULES", &c.TeamRoleRules)
+	envString("PRIORITY_CLASS", &c.PriorityClass)
+	envString("AUDIT_LOG", &c.AuditLog)
+	envString("NOTIFY_WEBHOOK", &c.NotifyWebhook)
+	envString("SCOREBOARD_NAMESPACE", &c.ScoreboardNamespace)
+	envString("SCOREBOARD_CONFIGMAP", &c.ScoreboardConfigMap)
+	envString("BASE_PATH", &c.BasePath)
+	envString("EXEC_AUTH_URL", &c.ExecAuthURL)
+	envString("EXEC_AUTH_SECRET", &c.ExecAuthSecret)
+	envString("MESH_INJECTION", &c.MeshInjection)
+	envString("MESH_INJECTION_LABEL", &c.MeshInjectionLabel)
+	envString("PULL_SECRET", &c.PullSecret)
+	envString("PULL_SECRET_NAMESPACE", &c.PullSecretNamespace)
+	envString("INGRESS_HOST", &c.IngressHost)
+	envString("TOKEN_SECRET_NAME", &c.TokenSecretName)
+	envString("INGRESS_CLASS", &c.IngressClass)
+	envString("INGRESS_SERVICE", &c.IngressService)
+
+	if audiences := os.Getenv("TOKEN_AUDIENCES"); audiences != "" {
+		c.TokenAudiences = strings.Split(audiences, ",")
+	}
+
+	if blocked := os.Getenv("BLOCKED_NAMES"); blocked != "" {
+		c.BlockedNames = strings.Split(blocked, ",")
+	}
+
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		c.TrustedProxies = strings.Split(proxies, ",")
+	}
+
+	for key, value := range map[string]*metav1.Duration{
+		"WAIT_READY":           &c.WaitReady,
+		"TOKEN_REFRESH_WINDOW": &c.TokenRefreshWindow,
+		"REQUEST_TIMEOUT":      &c.RequestTimeout,
+		"METRICS_INTERVAL":     &c.MetricsInterval,
+		"TOKEN_TTL":            &c.TokenTTL,
+		"MIN_TOKEN_TTL":        &c.MinTokenTTL,
+		"MAX_TOKEN_TTL":        &c.MaxTokenTTL,
+	} {
+		if env := os.Getenv(key); env != "" {
+			duration, err := time.ParseDuration(env)
+			if err != nil {
+				return fmt.Errorf("%s is not a duration: %s", key, env)
+			}
+
+			value.Duration = duration
+		}
+	}
+
+	if os.Getenv("AUTOMOUNT_SERVICE_ACCOUNT_TOKEN") != "" {
+		c.AutomountServiceAccountToken = new(bool)
+		if err := envBool("AUTOMOUNT_SERVICE_ACCOUNT_TOKEN", c.AutomountServiceAccountToken); err != nil {
+			return err
+		}
+	}
+
+	if err := envBool("ENABLE_PPROF", &c.EnablePprof); err != nil {
+		return err
+	}
+
+	if err := envBool("TOKEN_SECRET_FALLBACK", &c.TokenSecretFallback); err != nil {
+		return err
+	}
+
+	if err := envBool("WRITE_TOKEN_SECRET", &c.WriteTokenSecret); err != nil {
+		return err
+	}
+
+	if err := envBool("INSECURE_KUBECONFIG", &c.InsecureKubeconfig); err != nil {
+		return err
+	}
+
+	if err := envBool("SEED_SECRETS", &c.SeedSecrets); err != nil {
+		return err
+	}
+
+	if err := envBool("POST_CREATE_FATAL", &c.PostCreateFatal); err != nil {
+		return err
+	}
+
+	if err := envBool("TEAM_ROLE_ONLY", &c.TeamRoleOnly); err != nil {
+		return err
+	}
+
+	if err := envInt("SETUP_ATTEMPTS", &c.SetupAttempts); err != nil {
+		return err
+	}
+
+	if err := envInt("MAX_TEAMS", &c.MaxTeams); err != nil {
+		return err
+	}
+
+	if err := envInt("MAX_TEAM_NAME_LENGTH", &c.MaxTeamNameLength); err != nil {
+		return err
+	}
+
+	if port := os.Getenv("INGRESS_SERVICE_PORT"); port != "" {
+		value, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			return fmt.Errorf("INGRESS_SERVICE_PORT is not an int: %s", port)
+		}
+
+		c.IngressServicePort = int32(value)
+	}
+
+	if maxBody := os.Getenv("MAX_BODY_BYTES"); maxBody != "" {
+		var err error
+		c.MaxBodyBytes, err = strconv.ParseInt(maxBody, 10, 64)
+		if err != nil {
+			return fmt.Errorf("MAX_BODY_BYTES is not an int: %s", maxBody)
+		}
+	}
+
+	if labels := os.Getenv("NAMESPACE_LABELS"); labels != "" {
+		var err error
+		c.NamespaceLabels, err = k8s.ParseLabels(labels)
+		if err != nil {
+			return fmt.Errorf("NAMESPACE_LABELS is not valid: %w", err)
+		}
+	}
+
+	if annotations := os.Getenv("NAMESPACE_ANNOTATIONS"); annotations != "" {
+		var err error
+		c.NamespaceAnnotations, err = k8s.ParseAnnotations(annotations)
+		if err != nil {
+			return fmt.Errorf("NAMESPACE_ANNOTATIONS is not valid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c Config) validate() error {
+	if c.Endpoint == "" {
+		return fmt.Errorf("ENDPOINT is not set")
+	}
+
+	if c.CA == "" {
+		return fmt.Errorf("CA is not set")
+	}
+
+	if c.SetupAttempts < 0 {
+		return fmt.Errorf("setup attempts can not be negative: %d", c.SetupAttempts)
+	}
+
+	if c.MaxTeams < 0 {
+		return fmt.Errorf("max teams can not be negative: %d", c.MaxTeams)
+	}
+
+	if c.TokenRefreshWindow.Duration < 0 {
+		return fmt.Errorf("token refresh window can not be negative: %s", c.TokenRefreshWindow.Duration)
+	}
+
+	if c.WaitReady.Duration < 0 {
+		return fmt.Errorf("wait ready can not be negative: %s", c.WaitReady.Duration)
+	}
+
+	if c.RequestTimeout.Duration <= 0 {
+		return fmt.Errorf("request timeout must be positive: %s", c.RequestTimeout.Duration)
+	}
+
+	if c.MetricsInterval.Duration < time.Second {
+		return fmt.Errorf("metrics interval must be at least a second: %s", c.MetricsInterval.Duration)
+	}
+
+	// team names are namespace names, which can be at most 63 characters
+	if c.MaxTeamNameLength < 2 || c.MaxTeamNameLength > 63 {
+		return fmt.Errorf("max team name length must be between 2 and 63: %d", c.MaxTeamNameLength)
+	}
+
+	// the API server refuses tokens shorter than 10 minutes
+	if c.MinTokenTTL.Duration < 10*time.Minute {
+		return fmt.Errorf("min token ttl must be at least 10m: %s", c.MinTokenTTL.Duration)
+	}
+
+	if c.TokenTTL.Duration < c.MinTokenTTL.Duration || c.TokenTTL.Duration > c.MaxTokenTTL.Duration {
+		return fmt.Errorf("token ttl must be between %s and %s: %s", c.MinTokenTTL.Duration, c.MaxTokenTTL.Duration, c.TokenTTL.Duration)
+	}
+
+	if c.MaxBodyBytes < 1 {
+		return fmt.Errorf("max body bytes must be positive: %d", c.MaxBodyBytes)
+	}
+
+	if _, err := c.trustedProxies(); err != nil {
+		return err
+	}
+
+	if c.NotifyWebhook != "" {
+		webhook, err := url.Parse(c.NotifyWebhook)
+		if err != nil || (webhook.Scheme != "https" && webhook.Scheme != "http") || webhook.Host == "" {
+			return fmt.Errorf("notify webhook is not an http(s) URL")
+		}
+	}
+
+	if errs := validation.IsValidLabelValue(c.EventName); len(errs) > 0 {
+		return fmt.Errorf("event name is not a valid label value: %s", strings.Join(errs, ", "))
+	}
+
+	if c.SecretProvider == k8s.SECRET_PROVIDER_UNIQUE_COORDINATES {
+		if errs := validation.IsDNS1123Label(c.CoordinatesNamespace); len(errs) > 0 {
+			return fmt.Errorf("coordinates namespace is not valid: %s", strings.Join(errs, ", "))
+		}
+
+		if errs := validation.IsDNS1123Subdomain(c.CoordinatesConfigMap); len(errs) > 0 {
+			return fmt.Errorf("coordinates config map name is not valid: %s", strings.Join(errs, ", "))
+		}
+	}
+
+	if c.Instructions != "" {
+		if errs := validation.IsDNS1123Subdomain(c.InstructionsConfigMap); len(errs) > 0 {
+			return fmt.Errorf("instructions config map name is not valid: %s", strings.Join(errs, ", "))
+		}
+	}
+
+	if c.WriteTokenSecret {
+		if errs := validation.IsDNS1123Subdomain(c.TokenSecretName); len(errs) > 0 {
+			return fmt.Errorf("token secret name is not valid: %s", strings.Join(errs, ", "))
+		}
+	}
+
+	if c.TeamRoleOnly && c.TeamRoleRules == "" {
+		return fmt.Errorf("TEAM_ROLE_ONLY needs TEAM_ROLE_RULES")
+	}
+
+	if c.BindMode != "" && c.BindMode != k8s.BIND_GROUP && c.BindMode != k8s.BIND_SERVICE_ACCOUNT {
+		return fmt.Errorf("bind mode is not valid: %s", c.BindMode)
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("both TLS cert and key must be set to enable TLS")
+	}
+
+	if (c.ExecAuthURL == "") != (c.ExecAuthSecret == "") {
+		return fmt.Errorf("both exec auth URL and secret must be set to enable exec auth")
+	}
+
+	if c.MeshInjection != "enabled" && c.MeshInjection != "disabled" {
+		return fmt.Errorf("mesh injection must be enabled or disabled: %s", c.MeshInjection)
+	}
+
+	if errs := validation.IsQualifiedName(c.MeshInjectionLabel); c.MeshInjection == "disabled" && len(errs) > 0 {
+		return fmt.Errorf("mesh injection label %q is not valid: %s", c.MeshInjectionLabel, strings.Join(errs, ", "))
+	}
+
+	if c.IngressServicePort < 0 || c.IngressServicePort > 65535 {
+		return fmt.Errorf("ingress service port is not a valid port: %d", c.IngressServicePort)
+	}
+
+	if c.BasePath != "" && !strings.HasPrefix(c.BasePath, "/") {
+		return fmt.Errorf("base path must start with /: %s", c.BasePath)
+	}
+
+	for _, pattern := range c.BlockedNames {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("blocked name %q is not a valid pattern: %w", pattern, err)
+		}
+	}
+
+	if err := k8s.ValidateLabels(c.NamespaceLabels); err != nil {
+		return fmt.Errorf("namespace labels are not valid: %w", err)
+	}
+
+	if err := k8s.ValidateAnnotations(c.NamespaceAnnotations); err != nil {
+		return fmt.Errorf("namespace annotations are not valid: %w", err)
+	}
+
+	return nil
+}
+
+// K8s resolves the config for k8s.Client, loading the files the config points at.
+func (c Config) K8s() (k8s.Config, error) {
+	cfg := k8s.DefaultConfig(c.Endpoint, c.CA)
+	cfg.Audiences = c.TokenAudiences
+	cfg.MaxTeams = c.MaxTeams
+	cfg.ScoreboardNamespace = c.ScoreboardNamespace
+	cfg.ScoreboardName = c.ScoreboardConfigMap
+	cfg.ExecURL = c.ExecAuthURL
+	cfg.ExecSecret = []byte(c.ExecAuthSecret)
+	cfg.WaitReady = c.WaitReady.Duration
+	cfg.TokenRefreshWindow = c.TokenRefreshWindow.Duration
+	cfg.TokenTTL = c.TokenTTL.Duration
+	cfg.MinTokenTTL = c.MinTokenTTL.Duration
+	cfg.MaxTokenTTL = c.MaxTokenTTL.Duration
+	cfg.AutomountToken = c.AutomountServiceAccountToken
+	cfg.TokenSecretFallback = c.TokenSecretFallback
+	cfg.InsecureSkipTLSVerify = c.InsecureKubeconfig
+	cfg.EventName = c.EventName
+	if c.WriteTokenSecret {
+		cfg.TokenSecretName = c.TokenSecretName
+	}
+	cfg.NamespaceLabels = c.NamespaceLabels
+	cfg.NamespaceAnnotations = c.NamespaceAnnotations
+
+	if c.SetupAttempts > 0 {
+		cfg.Attempts = c.SetupAttempts
+	}
+
+	if c.KubeconfigContext != "" {
+		cfg.ContextName = c.KubeconfigContext
+	}
+
+	if c.BindMode != "" {
+		cfg.BindMode = c.BindMode
+	}
+
+	if c.MeshInjection == "disabled" {
+		cfg.MeshInjectionLabel = c.MeshInjectionLabel
+	}
+
+	cfg.PullSecret = k8s.PullSecretSpec{
+		Name:      c.PullSecret,
+		Namespace: c.PullSecretNamespace,
+	}
+
+	cfg.Ingress.Host = c.IngressHost
+	cfg.Ingress.ClassName = c.IngressClass
+	if c.IngressService != "" {
+		cfg.Ingress.ServiceName = c.IngressService
+	}
+
+	if c.IngressServicePort > 0 {
+		cfg.Ingress.ServicePort = c.IngressServicePort
+	}
+
+	var err error
+	if c.SeedSpec != "" {
+		if cfg.Seed, err = k8s.LoadSeedSpec(c.SeedSpec); err != nil {
+			return k8s.Config{}, fmt.Errorf("failed loading seed spec: %w", err)
+		}
+	}
+
+	if !c.SeedSecrets {
+		cfg.Seed.Secrets = nil
+	}
+
+	if c.Instructions != "" {
+		instructions, err := k8s.LoadInstructions(c.InstructionsConfigMap, c.Instructions)
+		if err != nil {
+			return k8s.Config{}, fmt.Errorf("failed loading instructions: %w", err)
+		}
+
+		cfg.Seed.ConfigMaps = append(cfg.Seed.ConfigMaps, instructions)
+	}
+
+	if c.PostCreate != "" {
+		if cfg.PostCreate, err = k8s.LoadPostCreateTemplates(c.PostCreate); err != nil {
+			return k8s.Config{}, fmt.Errorf("failed loading post-create templates: %w", err)
+		}
+
+		cfg.PostCreateFatal = c.PostCreateFatal
+	}
+
+	if cfg.SecretProvider, err = k8s.NewSecretProvider(c.SecretProvider, c.CoordinatesNamespace, c.CoordinatesConfigMap); err != nil {
+		return k8s.Config{}, err
+	}
+
+	if c.KubeconfigTemplate != "" {
+		if cfg.Template, err = k8s.LoadKubeconfigTemplate(c.KubeconfigTemplate, c.Endpoint, c.CA); err != nil {
+			return k8s.Config{}, fmt.Errorf("failed loading kubeconfig template: %w", err)
+		}
+	}
+
+	if c.PlayerRoleRules != "" {
+		if cfg.PlayerRules, err = k8s.LoadPolicyRules(c.PlayerRoleRules); err != nil {
+			return k8s.Config{}, fmt.Errorf("failed loading player rules: %w", err)
+		}
+	}
+
+	if c.TeamRoleRules != "" {
+		if cfg.TeamRoleRules, err = k8s.LoadPolicyRules(c.TeamRoleRules); err != nil {
+			return k8s.Config{}, fmt.Errorf("failed loading team role rules: %w", err)
+		}
+
+		cfg.TeamRoleOnly = c.TeamRoleOnly
+	}
+
+	return cfg, nil
+}
+
+// trustedProxies parses TrustedProxies, where a single address is taken as a prefix of just that address
+func (c Config) trustedProxies() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, proxy := range c.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return nil, fmt.Errorf("trusted proxy is not an address or CIDR: %s", proxy)
+			}
+
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// API resolves the config for the api, opening the audit log the config points at.
+func (c Config) API() (api.Config, error) {
+	auditLog, err := audit.Open(c.AuditLog)
+	if err != nil {
+		return api.Config{}, fmt.Errorf("failed opening audit log: %w", err)
+	}
+
+	trustedProxies, err := c.trustedProxies()
+	if err != nil {
+		return api.Config{}, err
+	}
+
+	return api.Config{
+		AdminToken:         c.AdminToken,
+		TrustedProxies:     trustedProxies,
+		MaxBodyBytes:       c.MaxBodyBytes,
+		Audit:              auditLog,
+		Notifier:           notify.New(c.NotifyWebhook),
+		JoinCode:           c.JoinCode,
+		RulesURL:           c.RulesURL,
+		BlockedNames:       c.BlockedNames,
+		MaxTeamNameLength:  c.MaxTeamNameLength,
+		EnablePprof:        c.EnablePprof,
+		BasePath:           c.BasePath,
+		RequestTimeout:     c.RequestTimeout.Duration,
+		InsecureKubeconfig: c.InsecureKubeconfig,
+	}, nil
+}
+
+func envString(key string, value *string) {
+	if env := os.Getenv(key); env != "" {
+		*value = env
+	}
+}
+
+func envBool(key string, value *bool) error {
+	env := os.Getenv(key)
+	if env == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(env)
+	if err != nil {
+		return fmt.Errorf("%s is not a bool: %s", key, env)
+	}
+
+	*value = b
+	return nil
+}
+
+func envInt(key string, value *int) error {
+	env := os.Getenv(key)
+	if env == "" {
+		return nil
+	}
+
+	i, err := strconv.Atoi(env)
+	if err != nil {
+		return fmt.Errorf("%s is not an int: %s", key, env)
+	}
+
+	*value = i
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+)
+
+// loadTest loads the config from env only, with what every config needs set
+func loadTest(t *testing.T, env map[string]string) Config {
+	t.Helper()
+
+	t.Setenv("CONFIG", "")
+	t.Setenv("ENDPOINT", "kubernetes.example.com")
+	t.Setenv("CA", "Y2EtZGF0YQ==")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	return cfg
+}
+
+func TestSeedSecrets(t *testing.T) {
+	tests := []struct {
+		seedSecrets string
+		want        int
+	}{
+		{"", 1},
+		{"true", 1},
+		{"false", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run("SEED_SECRETS="+tt.seedSecrets, func(t *testing.T) {
+			cfg, err := loadTest(t, map[string]string{"SEED_SECRETS": tt.seedSecrets}).K8s()
+			if err != nil {
+				t.Fatalf("resolving k8s config: %v", err)
+			}
+
+			if len(cfg.Seed.Secrets) != tt.want {
+				t.Errorf("seeded secrets = %v, want %d", cfg.Seed.Secrets, tt.want)
+			}
+
+			if tt.want > 0 && cfg.Seed.Secrets[0].Name != k8s.COORDINATES_SECRET {
+				t.Errorf("seeded secret = %s, want %s", cfg.Seed.Secrets[0].Name, k8s.COORDINATES_SECRET)
+			}
+		})
+	}
+}
+
+func TestEnvBool(t *testing.T) {
+	cfg := loadTest(t, map[string]string{"ENABLE_PPROF": "true", "AUTOMOUNT_SERVICE_ACCOUNT_TOKEN": "false"})
+	if !cfg.EnablePprof {
+		t.Errorf("ENABLE_PPROF=true was not applied")
+	}
+
+	if cfg.AutomountServiceAccountToken == nil || *cfg.AutomountServiceAccountToken {
+		t.Errorf("AUTOMOUNT_SERVICE_ACCOUNT_TOKEN=false was not applied: %v", cfg.AutomountServiceAccountToken)
+	}
+
+	if loadTest(t, map[string]string{"AUTOMOUNT_SERVICE_ACCOUNT_TOKEN": ""}).AutomountServiceAccountToken != nil {
+		t.Errorf("AUTOMOUNT_SERVICE_ACCOUNT_TOKEN should be left unset")
+	}
+
+	// loadTest has set what every config needs for the rest of the test
+	t.Setenv("TEAM_ROLE_ONLY", "kanskje")
+	if _, err := Load(); err == nil || err.Error() != "TEAM_ROLE_ONLY is not a bool: kanskje" {
+		t.Errorf("expected TEAM_ROLE_ONLY to be rejected, got %v", err)
+	}
+}
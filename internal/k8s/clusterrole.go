@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var allVerbs = []string{"create", "get", "list", "watch", "update", "patch", "delete"}
+
+func DefaultPlayerRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"events", "pods", "pods/log", "secrets", "services"},
+			Verbs:     allVerbs,
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments"},
+			Verbs:     allVerbs,
+		},
+		{
+			APIGroups: []string{"networking.k8s.io"},
+			Resources: []string{"networkpolicies"},
+			Verbs:     allVerbs,
+		},
+	}
+}
+
+// LoadPolicyRules reads a list of RBAC policy rules from a YAML (or JSON) file.
+func LoadPolicyRules(path string) ([]rbacv1.PolicyRule, error) {
+	payload, err := os.ReadFile(path) // #nosec G304 -- path is operator supplied configuration
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []rbacv1.PolicyRule
+	if err := yaml.UnmarshalStrict(payload, &rules); err != nil {
+		return nil, fmt.Errorf("failed parsing policy rules: %w", err)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("policy rules are empty")
+	}
+
+	return rules, nil
+}
+
+// EnsureClusterRole creates the PLAYER_ROLE ClusterRole, or updates its rules to PlayerRules if it already exists.
+func (c Client) EnsureClusterRole(ctx context.Context) error {
+	clusterRole, err := c.client.RbacV1().ClusterRoles().Get(ctx, PLAYER_ROLE, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: PLAYER_ROLE,
+				Labels: map[string]string{
+					MANAGED_BY: "havnesjef",
+				},
+			},
+			Rules: c.PlayerRules,
+		}
+
+		if _, err := c.client.RbacV1().ClusterRoles().Create(ctx, clusterRole, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+
+		c.logger(ctx).Info("Created ClusterRole", "name", PLAYER_ROLE)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(clusterRole.Rules, c.PlayerRules) {
+		c.logger(ctx).Info("Found ClusterRole", "name", PLAYER_ROLE)
+		return nil
+	}
+
+	clusterRole.Rules = c.PlayerRules
+	if _, err := c.client.RbacV1().ClusterRoles().Update(ctx, clusterRole, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	c.logger(ctx).Info("Updated rules for ClusterRole", "name", PLAYER_ROLE)
+	return nil
+}
+
+func (c Client) ClusterRoleExists(ctx context.Context, name string) (bool, error) {
+	_, err := c.client.RbacV1().ClusterRoles().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
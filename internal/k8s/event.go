@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordTeamCreated emits an event in the team namespace. It is best-effort, and only logs failures.
+func (c Client) recordTeamCreated(ctx context.Context, team, role string) {
+	now := metav1.Now()
+	event := &apiv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: team + ".",
+		},
+		InvolvedObject: apiv1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       team,
+		},
+		Reason:         "TeamProvisioned",
+		Message:        fmt.Sprintf("havnesjef provisioned team %s with role %s", team, role),
+		Type:           apiv1.EventTypeNormal,
+		Source:         apiv1.EventSource{Component: "havnesjef"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	if _, err := c.client.CoreV1().Events(team).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		c.logger(ctx).Warn("failed recording team event", "error", err, "team", team)
+	}
+}
+
+// maxTeamEvents caps how many events GetTeamEvents returns
+const maxTeamEvents = 50
+
+type TeamEvent struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Object   string    `json:"object"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// GetTeamEvents returns the most recent events in the team namespace, newest first
+func (c Client) GetTeamEvents(ctx context.Context, team string) ([]TeamEvent, error) {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	list, err := c.client.CoreV1().Events(team).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]TeamEvent, 0, len(list.Items))
+	for _, event := range list.Items {
+		events = append(events, TeamEvent{
+			Type:     event.Type,
+			Reason:   event.Reason,
+			Message:  event.Message,
+			Object:   event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name,
+			Count:    event.Count,
+			LastSeen: lastSeen(event),
+		})
+	}
+
+	slices.SortFunc(events, func(a, b TeamEvent) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+
+	return events[:min(len(events), maxTeamEvents)], nil
+}
+
+// lastSeen picks the newest timestamp set, since events from the events.k8s.io API only set EventTime
+func lastSeen(event apiv1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
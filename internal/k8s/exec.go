@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// PLEESAH_EXEC_NONCE is signed into the exec key of the team, replacing it makes every exec key handed out for the team invalid
+const PLEESAH_EXEC_NONCE = "pleesah.io/exec-nonce"
+
+var ErrExecAuthDisabled = errors.New("exec auth is not enabled")
+
+var execKubeconfigTemplate = template.Must(template.ParseFS(templates, "templates/kubeconfig-exec.json"))
+
+// ExecKubeconfig renders a kubeconfig where kubectl fetches fresh tokens from havnesjef, instead of
+// carrying a token that expires. It authenticates with a key signing the team name and a new PLEESAH_EXEC_NONCE
+// with ExecSecret, so only the latest exec kubeconfig handed out for the team works.
+func (c Client) ExecKubeconfig(ctx context.Context, team string) (string, error) {
+	if len(c.ExecSecret) == 0 {
+		return "", ErrExecAuthDisabled
+	}
+
+	if _, err := c.lookupTeam(ctx, team); err != nil {
+		return "", err
+	}
+
+	nonce, err := c.renewExecNonce(ctx, team)
+	if err != nil {
+		return "", err
+	}
+
+	return c.renderExecKubeconfig(team, nonce)
+}
+
+// renewExecNonce replaces PLEESAH_EXEC_NONCE on the team namespace, and returns the new nonce
+func (c Client) renewExecNonce(ctx context.Context, team string) (string, error) {
+	nonce := rand.Text()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		namespace, err := c.getTeam(ctx, team)
+		if err != nil {
+			return err
+		}
+
+		if namespace.Annotations == nil {
+			namespace.Annotations = map[string]string{}
+		}
+
+		namespace.Annotations[PLEESAH_EXEC_NONCE] = nonce
+		return c.UpdateTeam(ctx, namespace)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed storing exec nonce: %w", err)
+	}
+
+	return nonce, nil
+}
+
+func (c Client) renderExecKubeconfig(team, nonce string) (string, error) {
+	tokenURL, err := url.JoinPath(c.ExecURL, "api/v1/team", team, "token")
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = execKubeconfigTemplate.Execute(&buffer, map[string]any{
+		"Name":     team,
+		"Context":  strings.ReplaceAll(c.ContextName, "{team}", team),
+		"Token":    c.execKey(team, nonce),
+		"TokenURL": tokenURL,
+		"Endpoint": c.Endpoint,
+		"CA":       c.CA,
+		"Insecure": c.InsecureSkipTLSVerify,
+	})
+	if err != nil {
+		return "", fmt.Errorf("rendering exec kubeconfig for %s: %w", team, err)
+	}
+
+	return normalizeKubeconfig(buffer.String()), nil
+}
+
+// ValidExecKey reports whether key is the exec key of team, from the latest exec kubeconfig handed out for it
+func (c Client) ValidExecKey(ctx context.Context, team, key string) bool {
+	if len(c.ExecSecret) == 0 {
+		return false
+	}
+
+	namespace, err := c.lookupTeam(ctx, team)
+	if err != nil {
+		return false
+	}
+
+	nonce := namespace.Annotations[PLEESAH_EXEC_NONCE]
+	if nonce == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(key), []byte(c.execKey(team, nonce)))
+}
+
+// ExecCredential mints a token for the team service account, in the form kubectl expects from an exec plugin
+func (c Client) ExecCredential(ctx context.Context, team string) (clientauthv1.ExecCredential, error) {
+	if _, err := c.lookupTeam(ctx, team); err != nil {
+		return clientauthv1.ExecCredential{}, err
+	}
+
+	token, err := c.createToken(ctx, team, c.TokenTTL)
+	if err != nil {
+		return clientauthv1.ExecCredential{}, err
+	}
+
+	credential := clientauthv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clientauthv1.SchemeGroupVersion.String(),
+			Kind:       "ExecCredential",
+		},
+		Status: &clientauthv1.ExecCredentialStatus{
+			Token: token.Status.Token,
+		},
+	}
+
+	// tokens from the token secret fallback do not expire
+	if !token.Status.ExpirationTimestamp.IsZero() {
+		credential.Status.ExpirationTimestamp = &token.Status.ExpirationTimestamp
+	}
+
+	return credential, nil
+}
+
+func (c Client) execKey(team, nonce string) string {
+	mac := hmac.New(sha256.New, c.ExecSecret)
+	mac.Write([]byte(team))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IngressSpec describes the Ingress created for each team when Host is set
+type IngressSpec struct {
+	// Host is the hostname, where {team} is replaced with the team name
+	Host        string
+	ClassName   string
+	ServiceName string
+	ServicePort int32
+}
+
+// createIngress routes the team host to the conventional service in the team namespace,
+// and returns the URL. An existing Ingress is left as it is.
+func (c Client) createIngress(ctx context.Context, team string) (string, error) {
+	host := strings.ReplaceAll(c.Ingress.Host, "{team}", team)
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   team,
+			Labels: map[string]string{MANAGED_BY: "havnesjef"},
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: c.Ingress.ServiceName,
+											Port: networkingv1.ServiceBackendPort{Number: c.Ingress.ServicePort},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if c.Ingress.ClassName != "" {
+		ingress.Spec.IngressClassName = &c.Ingress.ClassName
+	}
+
+	err := c.withRetry(func() error {
+		_, err := c.client.NetworkingV1().Ingresses(team).Create(ctx, ingress, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	return "https://" + host, nil
+}
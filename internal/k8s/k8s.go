@@ -1,23 +1,161 @@
 package k8s
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"text/template"
+	"time"
 
+	"github.com/navikt/pleesah-havnesjef/internal/request"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 )
 
 type Client struct {
-	client   *kubernetes.Clientset
-	log      *slog.Logger
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
+	mapper  meta.RESTMapper
+	log     *slog.Logger
+	// tokenSecrets is set when TokenSecretFallback is on and the cluster has no TokenRequest API
+	tokenSecrets bool
+	Config
+}
+
+type Config struct {
 	Endpoint string
 	CA       string
+	Seed     SeedSpec
+	// SecretProvider fills in the seeded secrets that have no data
+	SecretProvider SecretProvider
+	Attempts       int
+	Template       *template.Template
+	ContextName    string
+	Audiences      []string
+	// TokenTTL is the default token lifetime, teams can ask for between MinTokenTTL and MaxTokenTTL
+	TokenTTL      time.Duration
+	MinTokenTTL   time.Duration
+	MaxTokenTTL   time.Duration
+	MaxTeams      int
+	PlayerRules   []rbacv1.PolicyRule
+	BindMode      string
+	PriorityClass string
+
+	NamespaceLabels      map[string]string
+	NamespaceAnnotations map[string]string
+
+	// EventName is set as PLEESAH_EVENT on team namespaces, and teams from other events are left alone when it is set
+	EventName string
+
+	// TeamRoleRules are given to players through a Role in their own namespace when not empty,
+	// and TeamRoleOnly leaves out the binding to the PLAYER_ROLE ClusterRole
+	TeamRoleRules []rbacv1.PolicyRule
+	TeamRoleOnly  bool
+
+	// ScoreboardNamespace and ScoreboardName point at the ConfigMap with the quiz scores
+	ScoreboardNamespace string
+	ScoreboardName      string
+
+	// InsecureSkipTLSVerify leaves the CA out of team kubeconfigs and skips TLS verification,
+	// for players behind proxies that intercept TLS
+	InsecureSkipTLSVerify bool
+
+	// ExecURL is where kubectl reaches havnesjef, and ExecSecret signs the keys in exec kubeconfigs
+	ExecURL    string
+	ExecSecret []byte
+
+	// MeshInjectionLabel is set to disabled on team namespaces when not empty, to keep sidecars out of player pods
+	MeshInjectionLabel string
+
+	// PullSecret is copied to team namespaces, and used by their service accounts, when its Name is set
+	PullSecret PullSecretSpec
+
+	// Ingress is created for each team when its Host is set
+	Ingress IngressSpec
+
+	// AutomountToken sets AutomountServiceAccountToken on team service accounts, leaving the default when nil
+	AutomountToken *bool
+
+	// TokenSecretFallback uses long-lived token Secrets when the cluster has no TokenRequest API
+	TokenSecretFallback bool
+
+	// TokenSecretName is a Secret in the team namespace that gets every token handed out at creation and renewal, when not empty
+	TokenSecretName string
+
+	// TokenRefreshWindow makes creation and renewal refuse to mint a new token while the last one is valid for longer than
+	// the window, always minting when zero
+	TokenRefreshWindow time.Duration
+
+	// PostCreate are templates of extra objects created in the team namespace after the core resources.
+	// Failing to create them fails setting up the team when PostCreateFatal is set, and is only logged otherwise.
+	PostCreate      []*template.Template
+	PostCreateFatal bool
+
+	// WaitReady is how long to wait for the team namespace to become Active, not waiting when zero
+	WaitReady time.Duration
+}
+
+func DefaultConfig(endpoint, ca string) Config {
+	return Config{
+		Endpoint:       endpoint,
+		CA:             ca,
+		Seed:           DefaultSeedSpec(),
+		SecretProvider: CoordinatesProvider{},
+		Attempts:       defaultAttempts,
+		Template:       kubeconfigTemplate,
+		ContextName:    "pleesah-{team}",
+		TokenTTL:       24 * time.Hour,
+		MinTokenTTL:    10 * time.Minute,
+		MaxTokenTTL:    48 * time.Hour,
+		PlayerRules:    DefaultPlayerRules(),
+		BindMode:       BIND_GROUP,
+		Ingress: IngressSpec{
+			ServiceName: "app",
+			ServicePort: 80,
+		},
+	}
+}
+
+// New creates the client, asking the cluster whether it serves the TokenRequest API when TokenSecretFallback is on
+func New(client kubernetes.Interface, dynamicClient dynamic.Interface, log *slog.Logger, config Config) (Client, error) {
+	var mapper meta.RESTMapper
+	if len(config.PostCreate) > 0 {
+		mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client.Discovery()))
+	}
+
+	if unique, ok := config.SecretProvider.(*UniqueCoordinatesProvider); ok {
+		unique.client = client
+	}
+
+	c := Client{
+		client:  client,
+		dynamic: dynamicClient,
+		mapper:  mapper,
+		log:     log,
+		Config:  config,
+	}
+
+	if config.TokenSecretFallback {
+		supported, err := c.tokenRequestSupported()
+		if err != nil {
+			return Client{}, fmt.Errorf("failed checking for the TokenRequest API: %w", err)
+		}
+
+		c.tokenSecrets = !supported
+	}
+
+	return c, nil
 }
 
-func New(client *kubernetes.Clientset, log *slog.Logger, endpoint, ca string) Client {
-	return Client{
-		client:   client,
-		log:      log,
-		Endpoint: endpoint,
-		CA:       ca,
+// logger returns the client logger with the request id from ctx
+func (c Client) logger(ctx context.Context) *slog.Logger {
+	if id := request.ID(ctx); id != "" {
+		return c.log.With("request_id", id)
 	}
+
+	return c.log
 }
@@ -0,0 +1,305 @@
+// Package k8smock has an in-memory stand-in for k8s.Client, for the api tests to drive the handlers without a cluster.
+package k8smock
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+// Client keeps teams in memory. When Err is set every call fails with it.
+type Client struct {
+	Err error
+
+	mu         sync.Mutex
+	teams      map[string]k8s.Team
+	spectators map[string]bool
+	secrets    map[string]map[string]string
+}
+
+func New() *Client {
+	return &Client{
+		teams:      map[string]k8s.Team{},
+		spectators: map[string]bool{},
+		secrets:    map[string]map[string]string{},
+	}
+}
+
+func notFound(team string) error {
+	return k8serrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, team)
+}
+
+func (c *Client) SetupTeamResult(_ context.Context, team, hexcode, role string, ttl time.Duration) (k8s.TeamResult, error) {
+	if c.Err != nil {
+		return k8s.TeamResult{}, c.Err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.teams[team]; !ok {
+		c.teams[team] = k8s.Team{Name: team, Hexcode: hexcode, Created: time.Now()}
+		c.secrets[team] = map[string]string{k8s.COORDINATES_KEY: "0,0"}
+		c.spectators[team] = role == k8s.SPECTATOR_ROLE
+	}
+
+	result := k8s.TeamResult{
+		Team:           team,
+		Namespace:      team,
+		ServiceAccount: team,
+		TokenExpiry:    time.Now().Add(cmp.Or(ttl, 24*time.Hour)),
+	}
+	result.SetKubeconfig(fmt.Sprintf(`{"kind":"Config","current-context":%q}`, role))
+
+	return result, nil
+}
+
+func (c *Client) SetupTeamExec(ctx context.Context, team, hexcode, role string, replace bool) (k8s.TeamResult, error) {
+	if _, err := c.GetTeam(ctx, team); err == nil && !replace {
+		return k8s.TeamResult{}, &k8s.SetupError{Stage: k8s.STAGE_LOOKUP, Team: team, Err: k8s.ErrTeamExists}
+	}
+
+	result, err := c.SetupTeamResult(ctx, team, hexcode, role, 0)
+	if err != nil {
+		return k8s.TeamResult{}, err
+	}
+
+	result.TokenExpiry = time.Time{}
+	result.SetKubeconfig(fmt.Sprintf(`{"kind":"Config","current-context":%q,"users":[{"name":"exec"}]}`, role))
+	return result, nil
+}
+
+func (c *Client) RenewToken(ctx context.Context, team string) (string, error) {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return "", err
+	}
+
+	return `{"kind":"Config"}`, nil
+}
+
+func (c *Client) ReissueToken(ctx context.Context, team string) (string, error) {
+	return c.RenewToken(ctx, team)
+}
+
+func (c *Client) RotateServiceAccount(ctx context.Context, team string) (string, error) {
+	return c.RenewToken(ctx, team)
+}
+
+func (c *Client) PreviewKubeconfig() (string, error) {
+	return `{"kind":"Config"}`, nil
+}
+
+func (c *Client) ExecKubeconfig(ctx context.Context, team string) (string, error) {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return "", err
+	}
+
+	return `{"kind":"Config"}`, nil
+}
+
+// ValidExecKey accepts the team name as its key
+func (c *Client) ValidExecKey(ctx context.Context, team, key string) bool {
+	return team == key
+}
+
+func (c *Client) ExecCredential(ctx context.Context, team string) (clientauthv1.ExecCredential, error) {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return clientauthv1.ExecCredential{}, err
+	}
+
+	expiry := metav1.NewTime(time.Now().Add(24 * time.Hour))
+	return clientauthv1.ExecCredential{
+		Status: &clientauthv1.ExecCredentialStatus{Token: "mock-token", ExpirationTimestamp: &expiry},
+	}, nil
+}
+
+func (c *Client) GetTeam(_ context.Context, team string) (k8s.Team, error) {
+	if c.Err != nil {
+		return k8s.Team{}, c.Err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.teams[team]
+	if !ok {
+		return k8s.Team{}, notFound(team)
+	}
+
+	return t, nil
+}
+
+func (c *Client) ListTeams(ctx context.Context) ([]k8s.Team, error) {
+	teams, _, err := c.ListTeamsPage(ctx, 0, "")
+	return teams, err
+}
+
+func (c *Client) ListTeamsPage(_ context.Context, _ int64, _ string) ([]k8s.Team, string, error) {
+	if c.Err != nil {
+		return nil, "", c.Err
+	}
+
+	return c.list(false), "", nil
+}
+
+func (c *Client) ListSpectators(_ context.Context) ([]k8s.Team, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	return c.list(true), nil
+}
+
+func (c *Client) list(spectators bool) []k8s.Team {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	teams := []k8s.Team{}
+	for _, team := range c.teams {
+		if c.spectators[team.Name] == spectators {
+			teams = append(teams, team)
+		}
+	}
+
+	slices.SortFunc(teams, func(a, b k8s.Team) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return teams
+}
+
+func (c *Client) GetTeamSlots(ctx context.Context) (k8s.TeamSlots, error) {
+	teams, err := c.ListTeams(ctx)
+	if err != nil {
+		return k8s.TeamSlots{}, err
+	}
+
+	return k8s.TeamSlots{Used: len(teams), Unlimited: true}, nil
+}
+
+func (c *Client) DeleteTeam(ctx context.Context, team string) error {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.teams, team)
+	delete(c.spectators, team)
+	delete(c.secrets, team)
+	return nil
+}
+
+func (c *Client) TeamAddCoordinates(_ context.Context, team, minifiedCoordinates string) string {
+	return c.update(team, func(t *k8s.Team) { t.Progression = append(t.Progression, minifiedCoordinates) })
+}
+
+func (c *Client) TeamNextTask(_ context.Context, team string, task int) string {
+	return c.update(team, func(t *k8s.Team) { t.Task = task })
+}
+
+func (c *Client) update(team string, mutate func(*k8s.Team)) string {
+	if c.Err != nil {
+		return c.Err.Error()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.teams[team]
+	if !ok {
+		return "team was not found"
+	}
+
+	mutate(&t)
+	c.teams[team] = t
+	return ""
+}
+
+func (c *Client) GetTeamEvents(ctx context.Context, team string) ([]k8s.TeamEvent, error) {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	return []k8s.TeamEvent{}, nil
+}
+
+func (c *Client) GetTeamSecret(ctx context.Context, team string) (map[string]string, error) {
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.secrets[team], nil
+}
+
+func (c *Client) ReseedSecret(ctx context.Context, team string) (map[string]string, error) {
+	return c.GetTeamSecret(ctx, team)
+}
+
+func (c *Client) PatchQuota(ctx context.Context, team string, spec k8s.QuotaSpec) (map[string]string, error) {
+	if _, err := spec.ResourceList(); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"cpu": spec.CPU, "memory": spec.Memory, "pods": spec.Pods}, nil
+}
+
+// ReviewToken authenticates "mock-token" as the service account of the first team by name
+func (c *Client) ReviewToken(ctx context.Context, token string) (k8s.TokenIdentity, error) {
+	teams, err := c.ListTeams(ctx)
+	if err != nil {
+		return k8s.TokenIdentity{}, err
+	}
+
+	if token != "mock-token" || len(teams) == 0 {
+		return k8s.TokenIdentity{Error: "invalid token"}, nil
+	}
+
+	team := teams[0].Name
+	return k8s.TokenIdentity{
+		Authenticated: true,
+		Username:      "system:serviceaccount:" + team + ":" + team,
+		Namespace:     team,
+	}, nil
+}
+
+func (c *Client) ClusterRoleExists(_ context.Context, _ string) (bool, error) {
+	return c.Err == nil, c.Err
+}
+
+func (c *Client) MissingPermissions(_ context.Context) ([]string, error) {
+	return nil, c.Err
+}
+
+func (c *Client) GetScoreboard(_ context.Context) (map[string]string, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	return nil, k8s.ErrNoScoreboard
+}
+
+func (c *Client) IsDeploymentRunning(ctx context.Context, team, _ string) (bool, error) {
+	_, err := c.GetTeam(ctx, team)
+	return err == nil, err
+}
+
+func (c *Client) IsPodRunning(ctx context.Context, team, name string) (bool, error) {
+	return c.IsDeploymentRunning(ctx, team, name)
+}
+
+func (c *Client) IsServiceRunning(ctx context.Context, team, name string) (bool, error) {
+	return c.IsDeploymentRunning(ctx, team, name)
+}
@@ -1,63 +1,123 @@
 package k8s
 
 import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
+
+	"k8s.io/client-go/tools/clientcmd"
 )
 
+//go:embed templates
+var templates embed.FS
+
+var kubeconfigTemplate = template.Must(template.ParseFS(templates, "templates/kubeconfig.json"))
+
 func CreateHavnesjefConfig(token, endpoint, ca string) (string, error) {
-	kubeconfig := createKubeconfig("havnesjef", token, endpoint, ca)
+	kubeconfig, err := createKubeconfig(kubeconfigTemplate, "havnesjef", "pleesah", token, endpoint, ca, false)
+	if err != nil {
+		return "", err
+	}
+
 	path := filepath.Join(os.TempDir(), ".config")
-	err := os.WriteFile(path, []byte(kubeconfig), 0o600)
+	err = os.WriteFile(path, []byte(kubeconfig), 0o600)
 
 	return path, err
 }
 
-func createKubeconfig(team, token, endpoint, ca string) string {
-	var sb strings.Builder
-	tmpl := template.Must(template.New("kubeconfig").Parse(kubeconfigTemplate))
-	_ = tmpl.Execute(&sb, map[string]string{
+// LoadKubeconfigTemplate parses a kubeconfig template from file, and makes sure a sample
+// render is a JSON kubeconfig that kubectl is able to load.
+func LoadKubeconfigTemplate(path, endpoint, ca string) (*template.Template, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sample, err := createKubeconfig(tmpl, "sample-team", "pleesah-sample-team", "sample-token", endpoint, ca, false)
+	if err != nil {
+		return nil, fmt.Errorf("rendering sample kubeconfig: %w", err)
+	}
+
+	if !json.Valid([]byte(sample)) {
+		return nil, fmt.Errorf("rendered kubeconfig is not valid JSON")
+	}
+
+	if _, err := clientcmd.Load([]byte(sample)); err != nil {
+		return nil, fmt.Errorf("rendered kubeconfig can not be loaded: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// renderKubeconfig renders the kubeconfig template for a team, with the context named after ContextName
+func (c Client) renderKubeconfig(name, token string) (string, error) {
+	contextName := strings.ReplaceAll(c.ContextName, "{team}", name)
+	return createKubeconfig(c.Template, name, contextName, token, c.Endpoint, c.CA, c.InsecureSkipTLSVerify)
+}
+
+// PreviewKubeconfig renders the kubeconfig template with placeholders, without creating anything in the cluster
+func (c Client) PreviewKubeconfig() (string, error) {
+	return c.renderKubeconfig("sample-team", "sample-token")
+}
+
+// ValidateKubeconfigs renders the kubeconfigs teams get with placeholders, and makes sure kubectl is able to load them.
+func (c Client) ValidateKubeconfigs() error {
+	kubeconfig, err := c.PreviewKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	if _, err := clientcmd.Load([]byte(kubeconfig)); err != nil {
+		return fmt.Errorf("rendered kubeconfig can not be loaded: %w", err)
+	}
+
+	if len(c.ExecSecret) == 0 {
+		return nil
+	}
+
+	execKubeconfig, err := c.renderExecKubeconfig("sample-team", "sample-nonce")
+	if err != nil {
+		return err
+	}
+
+	if _, err := clientcmd.Load([]byte(execKubeconfig)); err != nil {
+		return fmt.Errorf("rendered exec kubeconfig can not be loaded: %w", err)
+	}
+
+	return nil
+}
+
+// createKubeconfig renders into a buffer, so a template failing halfway never hands out a truncated kubeconfig
+func createKubeconfig(tmpl *template.Template, team, contextName, token, endpoint, ca string, insecure bool) (string, error) {
+	var buffer bytes.Buffer
+	err := tmpl.Execute(&buffer, map[string]any{
 		"Name":     team,
+		"Context":  contextName,
 		"Token":    token,
 		"Endpoint": endpoint,
 		"CA":       ca,
+		"Insecure": insecure,
 	})
+	if err != nil {
+		return "", fmt.Errorf("rendering kubeconfig for %s: %w", team, err)
+	}
 
-	return sb.String()
+	return normalizeKubeconfig(buffer.String()), nil
 }
 
-const kubeconfigTemplate = `{
-    "apiVersion": "v1",
-    "clusters": [
-        {
-            "cluster": {
-                "certificate-authority-data": "{{ .CA }}",
-                "server": "https://{{ .Endpoint }}"
-            },
-            "name": "pleesah"
-        }
-    ],
-    "contexts": [
-        {
-            "context": {
-                "cluster": "pleesah",
-                "namespace": "{{ .Name }}",
-                "user": "pirat"
-            },
-            "name": "pleesah"
-        }
-    ],
-    "current-context": "pleesah",
-    "kind": "Config",
-    "preferences": {},
-    "users": [
-        {
-            "name": "pirat",
-            "user": {
-                "token": "{{ .Token }}"
-            }
-        }
-    ]
-}`
+// normalizeKubeconfig reindents the rendered kubeconfig and ends it with a single newline,
+// so the output does not depend on whitespace in the template
+func normalizeKubeconfig(kubeconfig string) string {
+	var buffer bytes.Buffer
+	if err := json.Indent(&buffer, []byte(strings.TrimSpace(kubeconfig)), "", "    "); err != nil {
+		return strings.TrimSpace(kubeconfig) + "\n"
+	}
+
+	buffer.WriteString("\n")
+	return buffer.String()
+}
@@ -0,0 +1,35 @@
+package k8s
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestRenderKubeconfigGolden(t *testing.T) {
+	client, _ := newTestClient(t, testConfig())
+
+	kubeconfig, err := client.renderKubeconfig("team-a", testToken)
+	if err != nil {
+		t.Fatalf("rendering kubeconfig: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "kubeconfig.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(kubeconfig), 0o600); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+
+	if kubeconfig != string(want) {
+		t.Errorf("kubeconfig does not match %s, rerun with -update if the change is intended\ngot:\n%s\nwant:\n%s", golden, kubeconfig, want)
+	}
+}
@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ParseLabels parses key=value,key=value into labels, validating both keys and values.
+func ParseLabels(s string) (map[string]string, error) {
+	labels, err := parseKeyValues(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return labels, ValidateLabels(labels)
+}
+
+// ParseAnnotations parses key=value,key=value into annotations, validating the keys.
+func ParseAnnotations(s string) (map[string]string, error) {
+	annotations, err := parseKeyValues(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return annotations, ValidateAnnotations(annotations)
+}
+
+func ValidateLabels(labels map[string]string) error {
+	return validateKeyValues(labels, validation.IsValidLabelValue)
+}
+
+func ValidateAnnotations(annotations map[string]string) error {
+	return validateKeyValues(annotations, func(string) []string { return nil })
+}
+
+func parseKeyValues(s string) (map[string]string, error) {
+	values := map[string]string{}
+	for pair := range strings.SplitSeq(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not on the form key=value", pair)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+func validateKeyValues(values map[string]string, validateValue func(string) []string) error {
+	for key, value := range values {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("key %q is not valid: %s", key, strings.Join(errs, ", "))
+		}
+
+		if errs := validateValue(value); len(errs) > 0 {
+			return fmt.Errorf("value %q for %s is not valid: %s", value, key, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var ErrInvalidTeamName = errors.New("team name can not be used")
+
+// generatedSuffixLength is how many characters the API server appends to a GenerateName
+const generatedSuffixLength = 5
+
+// ValidateTeamNames checks that every resource named after the team gets a name Kubernetes accepts,
+// so a team is rejected before anything is created instead of failing halfway through setup.
+func ValidateTeamNames(team string) error {
+	names := []struct {
+		resource string
+		name     string
+		validate func(string) []string
+	}{
+		{"namespace", team, validation.IsDNS1123Label},
+		{"serviceaccount", team, validation.IsDNS1123Subdomain},
+		{"rolebinding", team, validation.IsDNS1123Subdomain},
+		{"event", team + "." + strings.Repeat("x", generatedSuffixLength), validation.IsDNS1123Subdomain},
+	}
+
+	for _, n := range names {
+		if errs := n.validate(n.name); len(errs) > 0 {
+			return fmt.Errorf("%w: %s name %q is not valid: %s", ErrInvalidTeamName, n.resource, n.name, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
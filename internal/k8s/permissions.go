@@ -0,0 +1,141 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// basePermissions are what havnesjef needs across all namespaces to set up and manage teams with any config
+var basePermissions = []authorizationv1.ResourceAttributes{
+	{Verb: "create", Resource: "namespaces"},
+	{Verb: "get", Resource: "namespaces"},
+	{Verb: "list", Resource: "namespaces"},
+	{Verb: "update", Resource: "namespaces"},
+	{Verb: "delete", Resource: "namespaces"},
+	{Verb: "create", Resource: "serviceaccounts"},
+	{Verb: "get", Resource: "serviceaccounts"},
+	{Verb: "delete", Resource: "serviceaccounts"},
+	{Verb: "create", Resource: "serviceaccounts", Subresource: "token"},
+	{Verb: "create", Resource: "secrets"},
+	{Verb: "get", Resource: "secrets"},
+	{Verb: "update", Resource: "secrets"},
+	{Verb: "create", Resource: "configmaps"},
+	{Verb: "create", Resource: "events"},
+	{Verb: "list", Resource: "events"},
+	{Verb: "create", Resource: "resourcequotas"},
+	{Verb: "get", Resource: "resourcequotas"},
+	{Verb: "update", Resource: "resourcequotas"},
+	{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "rolebindings"},
+	{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterroles"},
+	{Verb: "get", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: PLAYER_ROLE},
+	{Verb: "update", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: PLAYER_ROLE},
+	{Verb: "escalate", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: PLAYER_ROLE},
+	{Verb: "get", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: SPECTATOR_ROLE},
+	{Verb: "create", Group: "authentication.k8s.io", Resource: "tokenreviews"},
+}
+
+// requiredPermissions adds what the enabled features need to basePermissions
+func (c Client) requiredPermissions() ([]authorizationv1.ResourceAttributes, error) {
+	permissions := slices.Clone(basePermissions)
+
+	if c.PullSecret.Name != "" {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Verb: "update", Resource: "serviceaccounts"})
+	}
+
+	if c.Ingress.Host != "" {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Verb: "create", Group: "networking.k8s.io", Resource: "ingresses"})
+	}
+
+	if len(c.TeamRoleRules) > 0 {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "roles"})
+	}
+
+	if c.PriorityClass != "" {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Verb: "get", Group: "scheduling.k8s.io", Resource: "priorityclasses"})
+	}
+
+	if c.ScoreboardNamespace != "" && c.ScoreboardName != "" {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Verb: "get", Namespace: c.ScoreboardNamespace, Resource: "configmaps", Name: c.ScoreboardName})
+	}
+
+	if unique, ok := c.SecretProvider.(*UniqueCoordinatesProvider); ok {
+		for _, verb := range []string{"get", "update"} {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Verb: verb, Namespace: unique.Namespace, Resource: "configmaps", Name: unique.Name})
+		}
+	}
+
+	for _, tmpl := range c.PostCreate {
+		objects, err := renderPostCreate(tmpl, postCreateData{Team: "team-example", Namespace: "team-example", Event: c.EventName})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			gvk := object.GroupVersionKind()
+			mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return nil, fmt.Errorf("failed finding resource for %s in %s: %w", gvk, tmpl.Name(), err)
+			}
+
+			permission := authorizationv1.ResourceAttributes{Verb: "create", Group: mapping.Resource.Group, Resource: mapping.Resource.Resource}
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
+// MissingPermissions asks the API server which of the required permissions havnesjef lacks.
+func (c Client) MissingPermissions(ctx context.Context) ([]string, error) {
+	permissions, err := c.requiredPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, attributes := range permissions {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}
+
+		result, err := c.client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Status.Allowed {
+			missing = append(missing, formatPermission(attributes))
+		}
+	}
+
+	return missing, nil
+}
+
+func formatPermission(attributes authorizationv1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if attributes.Subresource != "" {
+		resource += "/" + attributes.Subresource
+	}
+
+	if attributes.Group != "" {
+		resource += "." + attributes.Group
+	}
+
+	if attributes.Name != "" {
+		resource += " " + attributes.Name
+	}
+
+	if attributes.Namespace != "" {
+		return fmt.Sprintf("%s %s in %s", attributes.Verb, resource, attributes.Namespace)
+	}
+
+	return fmt.Sprintf("%s %s", attributes.Verb, resource)
+}
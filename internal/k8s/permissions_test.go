@@ -0,0 +1,79 @@
+package k8s
+
+import (
+	"slices"
+	"testing"
+	"text/template"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	networkPolicy := template.Must(template.New("deny-all.yaml").Parse(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: deny-all
+`))
+
+	tests := []struct {
+		name   string
+		enable func(*Config)
+		want   []string
+	}{
+		{"pull secret", func(c *Config) { c.PullSecret.Name = "ghcr" }, []string{"update serviceaccounts"}},
+		{"ingress", func(c *Config) { c.Ingress.Host = "{team}.example.com" }, []string{"create ingresses.networking.k8s.io"}},
+		{"team role", func(c *Config) { c.TeamRoleRules = []rbacv1.PolicyRule{{Verbs: []string{"get"}}} }, []string{"create roles.rbac.authorization.k8s.io"}},
+		{"priority class", func(c *Config) { c.PriorityClass = "pleesah" }, []string{"get priorityclasses.scheduling.k8s.io"}},
+		{"scoreboard", func(c *Config) {
+			c.ScoreboardNamespace = "pleesah-system"
+			c.ScoreboardName = "scoreboard"
+		}, []string{"get configmaps scoreboard in pleesah-system"}},
+		{"unique coordinates", func(c *Config) {
+			c.SecretProvider = NewUniqueCoordinatesProvider("pleesah-system", "havnesjef-coordinates")
+		}, []string{"get configmaps havnesjef-coordinates in pleesah-system", "update configmaps havnesjef-coordinates in pleesah-system"}},
+		{"post create", func(c *Config) { c.PostCreate = []*template.Template{networkPolicy} }, []string{"create networkpolicies.networking.k8s.io"}},
+	}
+
+	base, _ := newTestClient(t, testConfig())
+	basePermissions := formatPermissions(t, base)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.enable(&config)
+			client, clientset := newTestClient(t, config)
+			clientset.Resources = []*metav1.APIResourceList{{
+				GroupVersion: "networking.k8s.io/v1",
+				APIResources: []metav1.APIResource{{Name: "networkpolicies", Kind: "NetworkPolicy", Namespaced: true}},
+			}}
+
+			permissions := formatPermissions(t, client)
+			for _, want := range tt.want {
+				if slices.Contains(basePermissions, want) {
+					t.Errorf("%s is required without %s", want, tt.name)
+				}
+
+				if !slices.Contains(permissions, want) {
+					t.Errorf("%s is not required with %s: %v", want, tt.name, permissions)
+				}
+			}
+		})
+	}
+}
+
+func formatPermissions(t *testing.T, client Client) []string {
+	t.Helper()
+
+	permissions, err := client.requiredPermissions()
+	if err != nil {
+		t.Fatalf("listing required permissions: %v", err)
+	}
+
+	var formatted []string
+	for _, permission := range permissions {
+		formatted = append(formatted, formatPermission(permission))
+	}
+
+	return formatted
+}
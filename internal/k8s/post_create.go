@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// postCreateData is what post-create templates are rendered with
+type postCreateData struct {
+	Team      string
+	Namespace string
+	Event     string
+}
+
+// LoadPostCreateTemplates parses every .yaml and .yml file in dir, in name order, as templates of objects applied to new teams
+func LoadPostCreateTemplates(dir string) ([]*template.Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []*template.Template
+	for _, entry := range entries {
+		if entry.IsDir() || !slices.Contains([]string{".yaml", ".yml"}, filepath.Ext(entry.Name())) {
+			continue
+		}
+
+		payload, err := os.ReadFile(filepath.Join(dir, entry.Name())) // #nosec G304 -- dir is operator supplied configuration
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl, err := template.New(entry.Name()).Option("missingkey=error").Parse(string(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing %s: %w", entry.Name(), err)
+		}
+
+		// catch templates that don't render into objects now, rather than when the first team joins
+		if _, err := renderPostCreate(tmpl, postCreateData{Team: "team-example", Namespace: "team-example"}); err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, tmpl)
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no .yaml files in %s", dir)
+	}
+
+	return templates, nil
+}
+
+// renderPostCreate renders tmpl, and decodes the objects in it
+func renderPostCreate(tmpl *template.Template, data postCreateData) ([]*unstructured.Unstructured, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed rendering %s: %w", tmpl.Name(), err)
+	}
+
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(&buf, 4096)
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed decoding %s: %w", tmpl.Name(), err)
+		}
+
+		if len(object.Object) == 0 {
+			continue
+		}
+
+		if object.GetAPIVersion() == "" || object.GetKind() == "" || object.GetName() == "" {
+			return nil, fmt.Errorf("object in %s needs apiVersion, kind and metadata.name", tmpl.Name())
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// applyPostCreate creates the objects from PostCreate in the team namespace, leaving existing ones as they are
+func (c Client) applyPostCreate(ctx context.Context, team string) error {
+	for _, tmpl := range c.PostCreate {
+		objects, err := renderPostCreate(tmpl, postCreateData{Team: team, Namespace: team, Event: c.EventName})
+		if err != nil {
+			return err
+		}
+
+		for _, object := range objects {
+			gvk := object.GroupVersionKind()
+			mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return fmt.Errorf("failed finding resource for %s in %s: %w", gvk, tmpl.Name(), err)
+			}
+
+			if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+				return fmt.Errorf("%s %s in %s is not namespaced, only objects in the team namespace can be created", gvk.Kind, object.GetName(), tmpl.Name())
+			}
+
+			object.SetNamespace(team)
+			labels := object.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[MANAGED_BY] = "havnesjef"
+			object.SetLabels(labels)
+
+			err = c.withRetry(func() error {
+				_, err := c.dynamic.Resource(mapping.Resource).Namespace(team).Create(ctx, object, metav1.CreateOptions{})
+				return err
+			})
+			if err != nil && !k8serrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed creating %s %s: %w", strings.ToLower(gvk.Kind), object.GetName(), err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package k8s
+
+import (
+	"context"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c Client) PriorityClassExists(ctx context.Context, name string) (bool, error) {
+	_, err := c.client.SchedulingV1().PriorityClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package k8s
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// PullSecretSpec points at the imagePullSecret copied into every team namespace when Name is set
+type PullSecretSpec struct {
+	Name      string
+	Namespace string
+}
+
+// copyPullSecret copies the pull secret into the team namespace, leaving an existing copy as it is
+func (c Client) copyPullSecret(ctx context.Context, team string) error {
+	source, err := c.client.CoreV1().Secrets(c.PullSecret.Namespace).Get(ctx, c.PullSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   c.PullSecret.Name,
+			Labels: map[string]string{MANAGED_BY: "havnesjef"},
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+
+	err = c.withRetry(func() error {
+		_, err := c.client.CoreV1().Secrets(team).Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// addPullSecretToDefault lets pods using the default service account pull with the pull secret.
+// The default service account is created by a controller, so it is waited for.
+func (c Client) addPullSecretToDefault(ctx context.Context, team string) error {
+	serviceAccounts := c.client.CoreV1().ServiceAccounts(team)
+	reference := apiv1.LocalObjectReference{Name: c.PullSecret.Name}
+
+	return wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		serviceAccount, err := serviceAccounts.Get(ctx, "default", metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if slices.Contains(serviceAccount.ImagePullSecrets, reference) {
+			return true, nil
+		}
+
+		serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, reference)
+		_, err = serviceAccounts.Update(ctx, serviceAccount, metav1.UpdateOptions{})
+		if k8serrors.IsConflict(err) {
+			return false, nil
+		}
+
+		return err == nil, err
+	})
+}
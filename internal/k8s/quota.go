@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const TEAM_QUOTA = "pleesah-quota"
+
+// QuotaSpec holds the new limits for a team, empty values are left as they are
+type QuotaSpec struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+	Pods   string `json:"pods"`
+}
+
+// ResourceList parses the spec, and fails on values that are not quantities
+func (q QuotaSpec) ResourceList() (apiv1.ResourceList, error) {
+	resources := apiv1.ResourceList{}
+	for name, value := range map[apiv1.ResourceName]string{
+		apiv1.ResourceCPU:    q.CPU,
+		apiv1.ResourceMemory: q.Memory,
+		apiv1.ResourcePods:   q.Pods,
+	} {
+		if value == "" {
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid quantity: %s", name, value)
+		}
+
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("%s can not be negative: %s", name, value)
+		}
+
+		resources[name] = quantity
+	}
+
+	return resources, nil
+}
+
+// PatchQuota sets the limits in spec on the team ResourceQuota, creating it when missing,
+// and returns the limits in effect afterwards.
+func (c Client) PatchQuota(ctx context.Context, team string, spec QuotaSpec) (map[string]string, error) {
+	resources, err := spec.ResourceList()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.GetTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	quotas := c.client.CoreV1().ResourceQuotas(team)
+	quota, err := quotas.Get(ctx, TEAM_QUOTA, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		quota, err = quotas.Create(ctx, &apiv1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   TEAM_QUOTA,
+				Labels: map[string]string{MANAGED_BY: "havnesjef"},
+			},
+			Spec: apiv1.ResourceQuotaSpec{Hard: resources},
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		if quota.Spec.Hard == nil {
+			quota.Spec.Hard = apiv1.ResourceList{}
+		}
+
+		for name, quantity := range resources {
+			quota.Spec.Hard[name] = quantity
+		}
+
+		quota, err = quotas.Update(ctx, quota, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hard := map[string]string{}
+	for name, quantity := range quota.Spec.Hard {
+		hard[string(name)] = quantity.String()
+	}
+
+	return hard, nil
+}
@@ -0,0 +1,27 @@
+package k8s
+
+import (
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+const defaultAttempts = 5
+
+// withRetry runs fn until it succeeds, fails with a non-transient error, or Attempts is reached.
+func (c Client) withRetry(fn func() error) error {
+	backoff := wait.Backoff{
+		Steps:    max(c.Attempts, 1),
+		Duration: 200 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.1,
+	}
+
+	return retry.OnError(backoff, isTransient, fn)
+}
+
+func isTransient(err error) bool {
+	return k8serrors.IsServerTimeout(err) || k8serrors.IsTooManyRequests(err) || k8serrors.IsInternalError(err)
+}
@@ -0,0 +1,67 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RotateServiceAccount deletes and recreates the team service account, which makes every token handed
+// out for it unusable, replaces the exec nonce so earlier exec keys stop working too, and returns a kubeconfig with a fresh token.
+func (c Client) RotateServiceAccount(ctx context.Context, team string) (string, error) {
+	namespace, err := c.getTeam(ctx, team)
+	if err != nil {
+		return "", err
+	}
+
+	if !c.inEvent(namespace) {
+		return "", k8serrors.NewNotFound(apiv1.Resource("namespaces"), team)
+	}
+
+	if namespace.Labels[MANAGED_BY] != "havnesjef" {
+		return "", ErrTeamNotManaged
+	}
+
+	role := PLAYER_ROLE
+	if namespace.Labels["spectator"] == "true" {
+		role = SPECTATOR_ROLE
+	}
+
+	serviceAccounts := c.client.CoreV1().ServiceAccounts(team)
+	if err := serviceAccounts.Delete(ctx, team, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return "", err
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, 200*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		_, err := serviceAccounts.Get(ctx, team, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := c.createServiceAccount(ctx, team); err != nil {
+		return "", err
+	}
+
+	if err := c.createRoleBinding(ctx, team, role); err != nil {
+		return "", err
+	}
+
+	if len(c.ExecSecret) > 0 {
+		if _, err := c.renewExecNonce(ctx, team); err != nil {
+			return "", err
+		}
+	}
+
+	c.logger(ctx).Info("Rotated service account", "team", team)
+	return c.ReissueToken(ctx, team)
+}
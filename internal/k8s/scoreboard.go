@@ -0,0 +1,24 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var ErrNoScoreboard = errors.New("scoreboard is not configured")
+
+// GetScoreboard returns the data of the scoreboard ConfigMap
+func (c Client) GetScoreboard(ctx context.Context) (map[string]string, error) {
+	if c.ScoreboardNamespace == "" || c.ScoreboardName == "" {
+		return nil, ErrNoScoreboard
+	}
+
+	configMap, err := c.client.CoreV1().ConfigMaps(c.ScoreboardNamespace).Get(ctx, c.ScoreboardName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return configMap.Data, nil
+}
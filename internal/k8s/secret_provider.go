@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// SecretProvider generates the data for seeded secrets that have no data in the SeedSpec
+type SecretProvider interface {
+	Generate(team string) (map[string][]byte, error)
+}
+
+// SecretRegenerator is implemented by providers that keep giving a team the same data, and are able to give it new data when reseeding
+type SecretRegenerator interface {
+	Regenerate(team string) (map[string][]byte, error)
+}
+
+const (
+	SECRET_PROVIDER_COORDINATES        = "coordinates"
+	SECRET_PROVIDER_RANDOM_COORDINATES = "random-coordinates"
+	SECRET_PROVIDER_UNIQUE_COORDINATES = "unique-coordinates"
+	SECRET_PROVIDER_RANDOM_CODE        = "random-code"
+)
+
+// NewSecretProvider returns the provider with the given name. The unique coordinates are kept in the config map
+// stateName in stateNamespace.
+func NewSecretProvider(name, stateNamespace, stateName string) (SecretProvider, error) {
+	switch name {
+	case SECRET_PROVIDER_COORDINATES:
+		return CoordinatesProvider{}, nil
+	case SECRET_PROVIDER_RANDOM_COORDINATES:
+		return CoordinatesProvider{Random: true}, nil
+	case SECRET_PROVIDER_UNIQUE_COORDINATES:
+		return NewUniqueCoordinatesProvider(stateNamespace, stateName), nil
+	case SECRET_PROVIDER_RANDOM_CODE:
+		return CodeProvider{Length: 8}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret provider: %s", name)
+	}
+}
+
+// CoordinatesProvider gives every team the same coordinates under COORDINATES_KEY, or random ones when Random is set
+type CoordinatesProvider struct {
+	Random bool
+}
+
+func (p CoordinatesProvider) Generate(string) (map[string][]byte, error) {
+	coordinates := "59.9124° N, 10.7962° E"
+	if p.Random {
+		coordinates = randomCoordinates()
+	}
+
+	return map[string][]byte{
+		COORDINATES_KEY: []byte(coordinates),
+	}, nil
+}
+
+// Regenerate always gives random coordinates, so reseeding sends the team somewhere new
+func (p CoordinatesProvider) Regenerate(team string) (map[string][]byte, error) {
+	p.Random = true
+	return p.Generate(team)
+}
+
+const CODE_KEY = "KODE"
+
+// codeCharacters leaves out characters that are easy to mix up, like 0 and O
+const codeCharacters = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// CodeProvider gives each team a random code of Length characters under CODE_KEY
+type CodeProvider struct {
+	Length int
+}
+
+func (p CodeProvider) Generate(string) (map[string][]byte, error) {
+	random := make([]byte, p.Length)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	code := make([]byte, p.Length)
+	for i, b := range random {
+		code[i] = codeCharacters[int(b)%len(codeCharacters)]
+	}
+
+	return map[string][]byte{
+		CODE_KEY: code,
+	}, nil
+}
@@ -0,0 +1,220 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// SeedSpec describes the secrets and config maps that are created in every team namespace.
+// Secrets without data get theirs from the SecretProvider.
+type SeedSpec struct {
+	Secrets    []ObjectSpec `json:"secrets"`
+	ConfigMaps []ObjectSpec `json:"configMaps"`
+}
+
+type ObjectSpec struct {
+	Name string            `json:"name"`
+	Data map[string]string `json:"data"`
+}
+
+const (
+	COORDINATES_SECRET = "koordinatene-mine"
+	COORDINATES_KEY    = "KOORDINATER"
+)
+
+var ErrNoSeedSecrets = errors.New("no secrets are generated in this event")
+
+func DefaultSeedSpec() SeedSpec {
+	return SeedSpec{
+		Secrets: []ObjectSpec{
+			{
+				Name: COORDINATES_SECRET,
+			},
+		},
+	}
+}
+
+// LoadSeedSpec reads a SeedSpec from a YAML (or JSON) file.
+func LoadSeedSpec(path string) (SeedSpec, error) {
+	payload, err := os.ReadFile(path) // #nosec G304 -- path is operator supplied configuration
+	if err != nil {
+		return SeedSpec{}, err
+	}
+
+	var spec SeedSpec
+	if err := yaml.UnmarshalStrict(payload, &spec); err != nil {
+		return SeedSpec{}, fmt.Errorf("failed parsing seed spec: %w", err)
+	}
+
+	for _, object := range append(spec.Secrets, spec.ConfigMaps...) {
+		if object.Name == "" {
+			return SeedSpec{}, fmt.Errorf("seed spec has an object without name")
+		}
+	}
+
+	return spec, nil
+}
+
+// LoadInstructions reads the game instructions from path into a config map called name, keyed by the file name,
+// so players can read them with kubectl.
+func LoadInstructions(name, path string) (ObjectSpec, error) {
+	payload, err := os.ReadFile(path) // #nosec G304 -- path is operator supplied configuration
+	if err != nil {
+		return ObjectSpec{}, err
+	}
+
+	key := filepath.Base(path)
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return ObjectSpec{}, fmt.Errorf("file name %q can not be a config map key: %s", key, strings.Join(errs, ", "))
+	}
+
+	return ObjectSpec{
+		Name: name,
+		Data: map[string]string{
+			key: string(payload),
+		},
+	}, nil
+}
+
+// seedTeam creates the secrets and config maps from Seed in the team namespace, leaving existing ones untouched.
+func (c Client) seedTeam(ctx context.Context, team string) error {
+	for _, spec := range c.Seed.Secrets {
+		secret := apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: spec.Name,
+			},
+			StringData: spec.Data,
+		}
+
+		if len(spec.Data) == 0 {
+			data, err := c.SecretProvider.Generate(team)
+			if err != nil {
+				return fmt.Errorf("failed generating secret %s: %w", spec.Name, err)
+			}
+
+			secret.Data = data
+		}
+
+		err := c.withRetry(func() error {
+			_, err := c.client.CoreV1().Secrets(team).Create(ctx, &secret, metav1.CreateOptions{})
+			return err
+		})
+		if err != nil && !k8serrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed creating secret %s: %w", spec.Name, err)
+		}
+	}
+
+	for _, spec := range c.Seed.ConfigMaps {
+		configMap := apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: spec.Name,
+			},
+			Data: spec.Data,
+		}
+
+		err := c.withRetry(func() error {
+			_, err := c.client.CoreV1().ConfigMaps(team).Create(ctx, &configMap, metav1.CreateOptions{})
+			return err
+		})
+		if err != nil && !k8serrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed creating config map %s: %w", spec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetTeamSecret returns the values of all seeded secrets in the team namespace.
+func (c Client) GetTeamSecret(ctx context.Context, team string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, spec := range c.Seed.Secrets {
+		secret, err := c.client.CoreV1().Secrets(team).Get(ctx, spec.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range secret.Data {
+			values[key] = string(value)
+		}
+	}
+
+	return values, nil
+}
+
+// ReseedSecret regenerates the secrets the SecretProvider fills in for the team, creating them if needed.
+func (c Client) ReseedSecret(ctx context.Context, team string) (map[string]string, error) {
+	generate := c.SecretProvider.Generate
+	if regenerator, ok := c.SecretProvider.(SecretRegenerator); ok {
+		generate = regenerator.Regenerate
+	}
+
+	values := map[string]string{}
+	secrets := c.client.CoreV1().Secrets(team)
+	for _, spec := range c.Seed.Secrets {
+		if len(spec.Data) > 0 {
+			continue
+		}
+
+		data, err := generate(team)
+		if err != nil {
+			return nil, err
+		}
+
+		secret, err := secrets.Get(ctx, spec.Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			secret = &apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: spec.Name,
+				},
+				Data: data,
+			}
+
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		} else if err == nil {
+			secret.Data = data
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range data {
+			values[key] = string(value)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, ErrNoSeedSecrets
+	}
+
+	return values, nil
+}
+
+func randomCoordinates() string {
+	// #nosec G404 -- coordinates are game content, and not used for anything security related
+	latitude, longitude := rand.Float64()*180-90, rand.Float64()*360-180
+
+	latitudeDirection, longitudeDirection := "N", "E"
+	if latitude < 0 {
+		latitudeDirection = "S"
+	}
+
+	if longitude < 0 {
+		longitudeDirection = "W"
+	}
+
+	return fmt.Sprintf("%.4f° %s, %.4f° %s", math.Abs(latitude), latitudeDirection, math.Abs(longitude), longitudeDirection)
+}
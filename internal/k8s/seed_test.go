@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInstructionsConfigMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oppgave.md")
+	if err := os.WriteFile(path, []byte("Finn skatten"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	instructions, err := LoadInstructions("oppgave", path)
+	if err != nil {
+		t.Fatalf("loading instructions: %v", err)
+	}
+
+	config := testConfig()
+	config.Seed.ConfigMaps = append(config.Seed.ConfigMaps, instructions)
+	client, clientset := newTestClient(t, config)
+
+	if _, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0); err != nil {
+		t.Fatalf("setting up team: %v", err)
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps("team-a")
+	configMap, err := configMaps.Get(context.Background(), "oppgave", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting instructions: %v", err)
+	}
+
+	if configMap.Data["oppgave.md"] != "Finn skatten" {
+		t.Errorf("instructions = %v, want oppgave.md with the file content", configMap.Data)
+	}
+
+	// setting up the team again leaves the config map as it is
+	configMap.Data["oppgave.md"] = "endret"
+	if _, err := configMaps.Update(context.Background(), configMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0); err != nil {
+		t.Fatalf("setting up team again: %v", err)
+	}
+
+	configMap, err = configMaps.Get(context.Background(), "oppgave", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting instructions: %v", err)
+	}
+
+	if configMap.Data["oppgave.md"] != "endret" {
+		t.Errorf("existing instructions were overwritten: %v", configMap.Data)
+	}
+}
+
+func TestLoadInstructionsRejectsBadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opp gave.md")
+	if err := os.WriteFile(path, []byte("Finn skatten"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadInstructions("oppgave", path); err == nil {
+		t.Error("expected a file name with a space to be refused as config map key")
+	}
+}
@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Stages of SetupTeamResult, reported in SetupError
+const (
+	STAGE_VALIDATE        = "validate"
+	STAGE_ROLE            = "role"
+	STAGE_LOOKUP          = "lookup"
+	STAGE_CAPACITY        = "capacity"
+	STAGE_NAMESPACE       = "namespace"
+	STAGE_PULL_SECRET     = "pullsecret"
+	STAGE_SERVICE_ACCOUNT = "serviceaccount"
+	STAGE_SEED            = "seed"
+	STAGE_ROLE_BINDING    = "rolebinding"
+	STAGE_INGRESS         = "ingress"
+	STAGE_POST_CREATE     = "postcreate"
+	STAGE_READY           = "ready"
+	STAGE_TOKEN           = "token"
+	STAGE_KUBECONFIG      = "kubeconfig"
+)
+
+// Codes telling automation why setting up a team failed, without parsing the message
+const (
+	CODE_INVALID_NAME     = "invalid_name"
+	CODE_INVALID_TTL      = "invalid_ttl"
+	CODE_INVALID_REQUEST  = "invalid_request"
+	CODE_TEAM_EXISTS      = "team_exists"
+	CODE_TEAM_TERMINATING = "team_terminating"
+	CODE_EVENT_FULL       = "event_full"
+	CODE_NOT_SET_UP       = "not_set_up"
+	CODE_NOT_READY        = "not_ready"
+	CODE_TOKEN_VALID      = "token_still_valid"
+	CODE_INTERNAL         = "internal"
+)
+
+// SetupError tells which stage of setting up a team failed. The sentinel errors and
+// API errors it wraps can still be matched with errors.Is and the k8serrors helpers.
+type SetupError struct {
+	Stage string
+	Team  string
+	Err   error
+}
+
+func (e *SetupError) Error() string {
+	return fmt.Sprintf("setting up team %s failed at %s: %s", e.Team, e.Stage, e.Err)
+}
+
+func (e *SetupError) Unwrap() error {
+	return e.Err
+}
+
+// IsConflict reports whether the team can not be set up in its current state
+func (e *SetupError) IsConflict() bool {
+	return errors.Is(e.Err, ErrTeamTerminating) || errors.Is(e.Err, ErrEventFull) || errors.Is(e.Err, ErrOtherEvent) ||
+		errors.Is(e.Err, ErrTeamExists) || errors.Is(e.Err, ErrTokenStillValid) || k8serrors.IsConflict(e.Err) || k8serrors.IsAlreadyExists(e.Err)
+}
+
+// IsValidation reports whether the request itself was rejected
+func (e *SetupError) IsValidation() bool {
+	return errors.Is(e.Err, ErrInvalidTeamName) || errors.Is(e.Err, ErrInvalidTTL) || errors.Is(e.Err, ErrExecAuthDisabled) || k8serrors.IsInvalid(e.Err) || k8serrors.IsBadRequest(e.Err)
+}
+
+// IsTransient reports whether trying again later may succeed
+func (e *SetupError) IsTransient() bool {
+	return errors.Is(e.Err, ErrNotReady) || errors.Is(e.Err, ErrNotSetUp) || isTransient(e.Err) || k8serrors.IsServiceUnavailable(e.Err) || k8serrors.IsTimeout(e.Err)
+}
+
+// Code classifies the error as one of the CODE_ constants
+func (e *SetupError) Code() string {
+	switch {
+	case errors.Is(e.Err, ErrInvalidTeamName):
+		return CODE_INVALID_NAME
+	case errors.Is(e.Err, ErrInvalidTTL):
+		return CODE_INVALID_TTL
+	case e.IsValidation():
+		return CODE_INVALID_REQUEST
+	case errors.Is(e.Err, ErrEventFull):
+		return CODE_EVENT_FULL
+	case errors.Is(e.Err, ErrTeamTerminating):
+		return CODE_TEAM_TERMINATING
+	case errors.Is(e.Err, ErrTokenStillValid):
+		return CODE_TOKEN_VALID
+	case errors.Is(e.Err, ErrTeamNotManaged) || errors.Is(e.Err, ErrOtherEvent) || errors.Is(e.Err, ErrTeamExists) || k8serrors.IsAlreadyExists(e.Err) || k8serrors.IsConflict(e.Err):
+		return CODE_TEAM_EXISTS
+	case errors.Is(e.Err, ErrNotSetUp):
+		return CODE_NOT_SET_UP
+	case e.IsTransient():
+		return CODE_NOT_READY
+	default:
+		return CODE_INTERNAL
+	}
+}
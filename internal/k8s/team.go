@@ -2,31 +2,98 @@ package k8s
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"strconv"
+	"time"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
 	apiv1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 )
 
 const (
 	PLEESAH_TASK        = "pleesah.io/task"
 	PLEESAH_HEXCODE     = "pleesah.io/hexcode"
 	PLEESAH_COORDINATES = "pleesah.io/coordinates"
+	// Read by the cluster admission policy, which sets the priority class on pods in the namespace
+	PLEESAH_PRIORITY_CLASS = "pleesah.io/priority-class"
+
+	// PLEESAH_TOKEN_EXPIRES is when the last token handed out for the team expires, in RFC3339
+	PLEESAH_TOKEN_EXPIRES = "pleesah.io/token-expires"
+
+	// PLEESAH_EVENT labels team namespaces with EventName, when set
+	PLEESAH_EVENT = "pleesah.io/event"
+
+	MANAGED_BY = "app.kubernetes.io/managed-by"
+
+	PLAYER_ROLE    = "pleesah-player"
+	SPECTATOR_ROLE = "pleesah-spectator"
+
+	redacted = "[REDACTED]"
+
+	BIND_GROUP           = "group"
+	BIND_SERVICE_ACCOUNT = "serviceaccount"
+)
+
+var (
+	ErrTeamTerminating = errors.New("this team is being deleted, try again shortly")
+	ErrTeamNotManaged  = errors.New("namespace is not managed by havnesjef")
+	ErrEventFull       = errors.New("event is full, no more teams can join")
+	ErrNotReady        = errors.New("namespace did not become active in time")
+	ErrEmptyToken      = errors.New("failed to obtain a token for the team service account")
+	ErrNotSetUp        = errors.New("the game isn't set up yet, try again shortly")
+	ErrInvalidTTL      = errors.New("token ttl is not allowed")
+	ErrOtherEvent      = errors.New("team belongs to another event")
+	ErrTeamExists      = errors.New("team already exists")
+	ErrTokenStillValid = errors.New("the team already has a token that is still valid, and it can not be shown again")
 )
 
+// TeamResult describes what was created for a team
+type TeamResult struct {
+	Team           string    `json:"team"`
+	Namespace      string    `json:"namespace"`
+	ServiceAccount string    `json:"serviceAccount"`
+	TokenExpiry    time.Time `json:"tokenExpiry"`
+	Kubeconfig     string    `json:"kubeconfig"`
+	// KubeconfigBase64 and Command are the kubeconfig as base64, and as a single command writing it to ./config
+	KubeconfigBase64 string `json:"kubeconfigBase64"`
+	Command          string `json:"command"`
+	URL              string `json:"url,omitempty"`
+}
+
+// LogValue keeps the kubeconfig, and the token in it, out of the logs
+func (r TeamResult) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("team", r.Team),
+		slog.String("namespace", r.Namespace),
+		slog.String("serviceAccount", r.ServiceAccount),
+		slog.Time("tokenExpiry", r.TokenExpiry),
+		slog.String("kubeconfig", redacted),
+		slog.String("kubeconfigBase64", redacted),
+		slog.String("command", redacted),
+		slog.String("url", r.URL),
+	)
+}
+
 type Team struct {
-	Name        string   `json:"navn"`
-	Hexcode     string   `json:"hexKode"`
-	Progression []string `json:"progresjon"`
+	Name        string    `json:"navn"`
+	Hexcode     string    `json:"hexKode"`
+	Progression []string  `json:"progresjon"`
+	Task        int       `json:"oppgave"`
+	Created     time.Time `json:"opprettet"`
 }
 
 func (c Client) TeamAddCoordinates(ctx context.Context, team, minifiedCoordinates string) string {
-	log := c.log.With("team", team)
+	log := c.logger(ctx).With("team", team)
 	namespace, err := c.getTeam(ctx, team)
 	if err != nil {
 		log.Error("failed fetching team", "error", err)
@@ -49,7 +116,7 @@ func (c Client) TeamAddCoordinates(ctx context.Context, team, minifiedCoordinate
 
 	namespace.Annotations[PLEESAH_COORDINATES] = string(payload)
 	if err := c.UpdateTeam(ctx, namespace); err != nil {
-		c.log.Error("failed storing team", "error", err)
+		c.logger(ctx).Error("failed storing team", "error", err)
 	}
 
 	return ""
@@ -58,14 +125,14 @@ func (c Client) TeamAddCoordinates(ctx context.Context, team, minifiedCoordinate
 func (c Client) TeamNextTask(ctx context.Context, team string, task int) string {
 	namespace, err := c.getTeam(ctx, team)
 	if err != nil {
-		c.log.Error("failed fetching team", "error", err, "team", team)
+		c.logger(ctx).Error("failed fetching team", "error", err, "team", team)
 		return "team was not found"
 	}
 
 	oldTaskString := namespace.Annotations[PLEESAH_TASK]
 	oldTaskInt, err := strconv.Atoi(oldTaskString)
 	if err != nil {
-		c.log.Error("task is not int", "error", err, "team", team, "task", task)
+		c.logger(ctx).Error("task is not int", "error", err, "team", team, "task", task)
 		return fmt.Sprintf("failed parsing old task as int: %s", oldTaskString)
 	}
 
@@ -75,7 +142,7 @@ func (c Client) TeamNextTask(ctx context.Context, team string, task int) string
 
 	namespace.Annotations[PLEESAH_TASK] = fmt.Sprint(task)
 	if err := c.UpdateTeam(ctx, namespace); err != nil {
-		c.log.Error("failed updating with new task", "error", err, "team", team, "task", task)
+		c.logger(ctx).Error("failed updating with new task", "error", err, "team", team, "task", task)
 		return "failed updating with new task"
 	}
 
@@ -86,97 +153,546 @@ func (c Client) getTeam(ctx context.Context, teamName string) (*apiv1.Namespace,
 	return c.client.CoreV1().Namespaces().Get(ctx, teamName, metav1.GetOptions{})
 }
 
-func (c Client) SetupTeam(ctx context.Context, team, hexcode string) (string, error) {
+// inEvent reports whether the namespace belongs to the current event, which all do when EventName is not set
+func (c Client) inEvent(namespace *apiv1.Namespace) bool {
+	return c.EventName == "" || namespace.Labels[PLEESAH_EVENT] == c.EventName
+}
+
+// SetupTeamResult creates the team, and binds its service account to the ClusterRole role.
+// Only teams with PLAYER_ROLE are labeled as players, spectators are kept out of the treasure map.
+// The token lives for ttl, or TokenTTL when ttl is zero.
+func (c Client) SetupTeamResult(ctx context.Context, team, hexcode, role string, ttl time.Duration) (TeamResult, error) {
+	return c.setupTeam(ctx, team, hexcode, role, ttl, false, false)
+}
+
+// SetupTeamExec is SetupTeamResult handing out an exec kubeconfig instead of a token. The new exec key replaces the
+// one the team already has, so an existing team is refused with ErrTeamExists unless replace is set.
+func (c Client) SetupTeamExec(ctx context.Context, team, hexcode, role string, replace bool) (TeamResult, error) {
+	return c.setupTeam(ctx, team, hexcode, role, 0, true, replace)
+}
+
+func (c Client) setupTeam(ctx context.Context, team, hexcode, role string, ttl time.Duration, exec, replace bool) (TeamResult, error) {
+	if exec && len(c.ExecSecret) == 0 {
+		return TeamResult{}, &SetupError{Stage: STAGE_VALIDATE, Team: team, Err: ErrExecAuthDisabled}
+	}
+
+	if err := ValidateTeamNames(team); err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_VALIDATE, Team: team, Err: err}
+	}
+
+	if ttl == 0 {
+		ttl = c.TokenTTL
+	}
+
+	if ttl < c.MinTokenTTL || ttl > c.MaxTokenTTL {
+		err := fmt.Errorf("%w: must be between %s and %s", ErrInvalidTTL, c.MinTokenTTL, c.MaxTokenTTL)
+		return TeamResult{}, &SetupError{Stage: STAGE_VALIDATE, Team: team, Err: err}
+	}
+
+	// without the ClusterRole the team would get a RoleBinding granting nothing
+	exists, err := c.ClusterRoleExists(ctx, role)
+	if err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_ROLE, Team: team, Err: err}
+	}
+
+	if !exists {
+		return TeamResult{}, &SetupError{Stage: STAGE_ROLE, Team: team, Err: ErrNotSetUp}
+	}
+
+	existing, err := c.getTeam(ctx, team)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return TeamResult{}, &SetupError{Stage: STAGE_LOOKUP, Team: team, Err: err}
+	}
+
+	if err == nil && existing.Status.Phase == apiv1.NamespaceTerminating {
+		return TeamResult{}, &SetupError{Stage: STAGE_LOOKUP, Team: team, Err: ErrTeamTerminating}
+	}
+
+	if err == nil && !c.inEvent(existing) {
+		return TeamResult{}, &SetupError{Stage: STAGE_LOOKUP, Team: team, Err: ErrOtherEvent}
+	}
+
+	if err == nil && exec && !replace {
+		return TeamResult{}, &SetupError{Stage: STAGE_LOOKUP, Team: team, Err: ErrTeamExists}
+	}
+
+	if err == nil && !exec {
+		if err := c.checkTokenRefresh(existing); err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_TOKEN, Team: team, Err: err}
+		}
+	}
+
+	if k8serrors.IsNotFound(err) && role == PLAYER_ROLE && c.MaxTeams > 0 {
+		teams, err := c.ListTeams(ctx)
+		if err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_CAPACITY, Team: team, Err: err}
+		}
+
+		if len(teams) >= c.MaxTeams {
+			return TeamResult{}, &SetupError{Stage: STAGE_CAPACITY, Team: team, Err: ErrEventFull}
+		}
+	}
+
 	namespace := &apiv1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: team,
-			Annotations: map[string]string{
-				PLEESAH_TASK:        "0",
-				PLEESAH_HEXCODE:     hexcode,
-				PLEESAH_COORDINATES: "[]",
-			},
-			Labels: map[string]string{
-				"player": "true",
-			},
+			Name:        team,
+			Annotations: maps.Clone(c.NamespaceAnnotations),
+			Labels:      maps.Clone(c.NamespaceLabels),
 		},
 	}
 
-	_, err := c.client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	if namespace.Annotations == nil {
+		namespace.Annotations = map[string]string{}
+	}
+
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+
+	namespace.Annotations[PLEESAH_TASK] = "0"
+	namespace.Annotations[PLEESAH_HEXCODE] = hexcode
+	namespace.Annotations[PLEESAH_COORDINATES] = "[]"
+	namespace.Labels[MANAGED_BY] = "havnesjef"
+	if c.EventName != "" {
+		namespace.Labels[PLEESAH_EVENT] = c.EventName
+	}
+
+	if c.MeshInjectionLabel != "" {
+		namespace.Labels[c.MeshInjectionLabel] = "disabled"
+	}
+
+	if c.PriorityClass != "" {
+		namespace.Annotations[PLEESAH_PRIORITY_CLASS] = c.PriorityClass
+	}
+
+	if role == PLAYER_ROLE {
+		namespace.Labels["player"] = "true"
+	} else {
+		namespace.Labels["spectator"] = "true"
+	}
+
+	err = c.withRetry(func() error {
+		_, err := c.client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+		return err
+	})
+	if k8serrors.IsAlreadyExists(err) && exec && !replace {
+		return TeamResult{}, &SetupError{Stage: STAGE_NAMESPACE, Team: team, Err: ErrTeamExists}
+	}
+
 	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		return "", err
+		return TeamResult{}, &SetupError{Stage: STAGE_NAMESPACE, Team: team, Err: err}
+	}
+
+	if c.PullSecret.Name != "" {
+		if err := c.copyPullSecret(ctx, team); err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_PULL_SECRET, Team: team, Err: err}
+		}
+	}
+
+	serviceAccount, err := c.createServiceAccount(ctx, team)
+	if err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_SERVICE_ACCOUNT, Team: team, Err: err}
+	}
+
+	if c.PullSecret.Name != "" {
+		if err := c.addPullSecretToDefault(ctx, team); err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_PULL_SECRET, Team: team, Err: err}
+		}
+	}
+
+	if err := c.seedTeam(ctx, namespace.Name); err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_SEED, Team: team, Err: err}
+	}
+
+	if err := c.createRoleBinding(ctx, team, role); err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_ROLE_BINDING, Team: team, Err: err}
+	}
+
+	var url string
+	if c.Ingress.Host != "" {
+		url, err = c.createIngress(ctx, team)
+		if err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_INGRESS, Team: team, Err: err}
+		}
+	}
+
+	if err := c.applyPostCreate(ctx, team); err != nil {
+		if c.PostCreateFatal {
+			return TeamResult{}, &SetupError{Stage: STAGE_POST_CREATE, Team: team, Err: err}
+		}
+
+		c.logger(ctx).Warn("failed creating post-create objects", "team", team, "error", err)
+	}
+
+	if c.WaitReady > 0 {
+		if err := c.waitForActive(ctx, team); err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_READY, Team: team, Err: err}
+		}
+	}
+
+	result := TeamResult{
+		Team:           team,
+		Namespace:      namespace.Name,
+		ServiceAccount: serviceAccount.Name,
+		URL:            url,
+	}
+
+	if exec {
+		nonce, err := c.renewExecNonce(ctx, team)
+		if err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_TOKEN, Team: team, Err: err}
+		}
+
+		kubeconfig, err := c.renderExecKubeconfig(team, nonce)
+		if err != nil {
+			return TeamResult{}, &SetupError{Stage: STAGE_KUBECONFIG, Team: team, Err: err}
+		}
+
+		c.recordTeamCreated(ctx, team, role)
+		result.SetKubeconfig(kubeconfig)
+		return result, nil
+	}
+
+	// The token is minted last, so no failure after this point can leak it into errors or logs
+	token, err := c.createStaticToken(ctx, team, ttl)
+	if err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_TOKEN, Team: team, Err: err}
 	}
 
+	if err := c.publishToken(ctx, team, token); err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_TOKEN, Team: team, Err: fmt.Errorf("failed writing token secret: %w", err)}
+	}
+
+	kubeconfig, err := c.renderKubeconfig(team, token.Status.Token)
+	if err != nil {
+		return TeamResult{}, &SetupError{Stage: STAGE_KUBECONFIG, Team: team, Err: err}
+	}
+
+	c.recordTeamCreated(ctx, team, role)
+
+	result.TokenExpiry = token.Status.ExpirationTimestamp.Time
+	result.SetKubeconfig(kubeconfig)
+
+	return result, nil
+}
+
+// SetKubeconfig sets the kubeconfig along with the base64 and command forms of it
+func (r *TeamResult) SetKubeconfig(kubeconfig string) {
+	r.Kubeconfig = kubeconfig
+	r.KubeconfigBase64 = base64.StdEncoding.EncodeToString([]byte(kubeconfig))
+	r.Command = fmt.Sprintf("echo %s | base64 -d > config && export KUBECONFIG=./config", r.KubeconfigBase64)
+}
+
+// waitForActive polls the team namespace until its phase is Active, or WaitReady has passed
+func (c Client) waitForActive(ctx context.Context, team string) error {
+	err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, c.WaitReady, true, func(ctx context.Context) (bool, error) {
+		namespace, err := c.getTeam(ctx, team)
+		if err != nil {
+			return false, err
+		}
+
+		return namespace.Status.Phase == apiv1.NamespaceActive, nil
+	})
+	if wait.Interrupted(err) {
+		return fmt.Errorf("%w: %s is not active after %s", ErrNotReady, team, c.WaitReady)
+	}
+
+	return err
+}
+
+// createServiceAccount creates the team service account, leaving an existing one as it is
+func (c Client) createServiceAccount(ctx context.Context, team string) (*apiv1.ServiceAccount, error) {
 	serviceAccount := &apiv1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: team,
 		},
+		AutomountServiceAccountToken: c.AutomountToken,
 	}
 
-	_, err = c.client.CoreV1().ServiceAccounts(namespace.Name).Create(ctx, serviceAccount, metav1.CreateOptions{})
+	if c.PullSecret.Name != "" {
+		serviceAccount.ImagePullSecrets = []apiv1.LocalObjectReference{{Name: c.PullSecret.Name}}
+	}
+
+	err := c.withRetry(func() error {
+		_, err := c.client.CoreV1().ServiceAccounts(team).Create(ctx, serviceAccount, metav1.CreateOptions{})
+		return err
+	})
 	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		return "", err
+		return nil, err
 	}
 
-	oneDay := int64(86400)
-	tokenRequest := &authenticationv1.TokenRequest{
+	return serviceAccount, nil
+}
+
+// createRoleBinding binds the team to the ClusterRole role, leaving an existing binding as it is
+func (c Client) createRoleBinding(ctx context.Context, team, role string) error {
+	if role == PLAYER_ROLE && len(c.TeamRoleRules) > 0 {
+		if err := c.createTeamRole(ctx, team); err != nil {
+			return err
+		}
+
+		if c.TeamRoleOnly {
+			return nil
+		}
+	}
+
+	roleBinding := rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: team,
 		},
-		Spec: authenticationv1.TokenRequestSpec{
-			ExpirationSeconds: &oneDay,
+		Subjects: c.roleBindingSubjects(team),
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "ClusterRole",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     role,
+		},
+	}
+	err := c.withRetry(func() error {
+		_, err := c.client.RbacV1().RoleBindings(team).Create(ctx, &roleBinding, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// roleBindingSubjects binds either every service account in the team namespace, or only the team service account.
+func (c Client) roleBindingSubjects(team string) []rbacv1.Subject {
+	if c.BindMode == BIND_SERVICE_ACCOUNT {
+		return []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      team,
+				Namespace: team,
+			},
+		}
+	}
+
+	return []rbacv1.Subject{
+		{
+			Kind:     "Group",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     fmt.Sprintf("system:serviceaccounts:%s", team),
 		},
 	}
+}
+
+// RenewToken creates a new token for the team service account, and returns a kubeconfig using it.
+// It returns ErrTokenStillValid while the last token is valid for longer than TokenRefreshWindow.
+func (c Client) RenewToken(ctx context.Context, team string) (string, error) {
+	return c.renewToken(ctx, team, false)
+}
+
+// ReissueToken is RenewToken ignoring TokenRefreshWindow, for admins handing out kubeconfigs
+func (c Client) ReissueToken(ctx context.Context, team string) (string, error) {
+	return c.renewToken(ctx, team, true)
+}
 
-	token, err := c.client.CoreV1().ServiceAccounts(namespace.Name).CreateToken(ctx, serviceAccount.Name, tokenRequest, metav1.CreateOptions{})
+func (c Client) renewToken(ctx context.Context, team string, force bool) (string, error) {
+	namespace, err := c.lookupTeam(ctx, team)
 	if err != nil {
 		return "", err
 	}
 
-	secret := apiv1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "koordinatene-mine",
-		},
-		Data: map[string][]byte{
-			"KOORDINATER": []byte("59.9124° N, 10.7962° E"),
-		},
+	if !force {
+		if err := c.checkTokenRefresh(namespace); err != nil {
+			return "", err
+		}
 	}
 
-	_, err = c.client.CoreV1().Secrets(namespace.Name).Create(ctx, &secret, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
+	token, err := c.createStaticToken(ctx, team, c.TokenTTL)
+	if err != nil {
 		return "", err
 	}
 
-	roleBinding := rbacv1.RoleBinding{
+	if err := c.publishToken(ctx, team, token); err != nil {
+		return "", fmt.Errorf("failed writing token secret: %w", err)
+	}
+
+	return c.renderKubeconfig(team, token.Status.Token)
+}
+
+// checkTokenRefresh returns ErrTokenStillValid when PLEESAH_TOKEN_EXPIRES on namespace is further away than TokenRefreshWindow
+func (c Client) checkTokenRefresh(namespace *apiv1.Namespace) error {
+	if c.TokenRefreshWindow <= 0 || namespace == nil {
+		return nil
+	}
+
+	expiry, err := time.Parse(time.RFC3339, namespace.Annotations[PLEESAH_TOKEN_EXPIRES])
+	if err != nil {
+		return nil
+	}
+
+	if time.Until(expiry) > c.TokenRefreshWindow {
+		return fmt.Errorf("%w: expires %s", ErrTokenStillValid, expiry.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (c Client) createToken(ctx context.Context, team string, ttl time.Duration) (*authenticationv1.TokenRequest, error) {
+	if c.tokenSecrets {
+		return c.createTokenSecret(ctx, team)
+	}
+
+	expirationSeconds := int64(ttl.Seconds())
+	requested := time.Now().Add(ttl)
+	tokenRequest := &authenticationv1.TokenRequest{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: team,
 		},
-		Subjects: []rbacv1.Subject{
-			{
-				Kind:     "Group",
-				APIGroup: "rbac.authorization.k8s.io",
-				Name:     fmt.Sprintf("system:serviceaccounts:%s", team),
-			},
-		},
-		RoleRef: rbacv1.RoleRef{
-			Kind:     "ClusterRole",
-			APIGroup: "rbac.authorization.k8s.io",
-			Name:     "pleesah-player",
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+			Audiences:         c.Audiences,
 		},
 	}
-	_, err = c.client.RbacV1().RoleBindings(namespace.Name).Create(ctx, &roleBinding, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		return "", err
+
+	var token *authenticationv1.TokenRequest
+	err := c.withRetry(func() error {
+		var err error
+		token, err = c.client.CoreV1().ServiceAccounts(team).CreateToken(ctx, team, tokenRequest, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Status.Token == "" {
+		return nil, ErrEmptyToken
 	}
 
-	return createKubeconfig(team, token.Status.Token, c.Endpoint, c.CA), nil
+	// the cluster may cap the lifetime, e.g. with --service-account-max-token-expiration
+	expiry := token.Status.ExpirationTimestamp.Time
+	if diff := requested.Sub(expiry); diff > time.Minute || diff < -time.Minute {
+		c.logger(ctx).Warn("token expiry differs from what was requested", "team", team, "requested", requested, "expiry", expiry)
+	}
+
+	return token, nil
+}
+
+// createStaticToken creates a token for a kubeconfig, and records its expiry for TokenRefreshWindow. Tokens from
+// exec credentials are left out, since the kubeconfig fetches a new one whenever it needs to.
+func (c Client) createStaticToken(ctx context.Context, team string, ttl time.Duration) (*authenticationv1.TokenRequest, error) {
+	token, err := c.createToken(ctx, team, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	// tokens from the token secret fallback do not expire
+	if token.Status.ExpirationTimestamp.IsZero() {
+		return token, nil
+	}
+
+	if err := c.annotateTokenExpiry(ctx, team, token.Status.ExpirationTimestamp.Time); err != nil {
+		c.logger(ctx).Warn("failed annotating token expiry on namespace", "team", team, "error", err)
+	}
+
+	return token, nil
+}
+
+// annotateTokenExpiry sets PLEESAH_TOKEN_EXPIRES on the team namespace, so tools cleaning up namespaces know when the team is locked out
+func (c Client) annotateTokenExpiry(ctx context.Context, team string, expiry time.Time) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		namespace, err := c.getTeam(ctx, team)
+		if err != nil {
+			return err
+		}
+
+		if namespace.Annotations == nil {
+			namespace.Annotations = map[string]string{}
+		}
+
+		namespace.Annotations[PLEESAH_TOKEN_EXPIRES] = expiry.UTC().Format(time.RFC3339)
+		return c.UpdateTeam(ctx, namespace)
+	})
+}
+
+// GetTeam returns the team, or a NotFound error if the namespace is not a player or spectator team.
+func (c Client) GetTeam(ctx context.Context, team string) (Team, error) {
+	namespace, err := c.lookupTeam(ctx, team)
+	if err != nil {
+		return Team{}, err
+	}
+
+	return namespaceToTeam(*namespace), nil
+}
+
+// lookupTeam returns the namespace of a player or spectator team havnesjef manages in the current event,
+// and a NotFound error for every other namespace, so tokens are never handed out for them
+func (c Client) lookupTeam(ctx context.Context, team string) (*apiv1.Namespace, error) {
+	namespace, err := c.getTeam(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	if (namespace.Labels["player"] != "true" && namespace.Labels["spectator"] != "true") ||
+		namespace.Labels[MANAGED_BY] != "havnesjef" || !c.inEvent(namespace) {
+		return nil, k8serrors.NewNotFound(apiv1.Resource("namespaces"), team)
+	}
+
+	return namespace, nil
 }
 
 func (c Client) ListTeams(ctx context.Context) ([]Team, error) {
+	teams, _, err := c.ListTeamsPage(ctx, 0, "")
+	return teams, err
+}
+
+// TeamSlots tells how many teams have joined, out of MaxTeams
+type TeamSlots struct {
+	Max       int  `json:"max"`
+	Used      int  `json:"used"`
+	Remaining int  `json:"remaining"`
+	Full      bool `json:"full"`
+	Unlimited bool `json:"unlimited"`
+}
+
+// GetTeamSlots counts how many more teams can join before MaxTeams is reached
+func (c Client) GetTeamSlots(ctx context.Context) (TeamSlots, error) {
+	teams, err := c.ListTeams(ctx)
+	if err != nil {
+		return TeamSlots{}, err
+	}
+
+	if c.MaxTeams == 0 {
+		return TeamSlots{Used: len(teams), Unlimited: true}, nil
+	}
+
+	remaining := max(c.MaxTeams-len(teams), 0)
+	return TeamSlots{
+		Max:       c.MaxTeams,
+		Used:      len(teams),
+		Remaining: remaining,
+		Full:      remaining == 0,
+	}, nil
+}
+
+// ListTeamsPage lists at most limit teams, starting from the continue token from the previous page.
+// The returned continue token is empty on the last page, and a limit of 0 lists every team.
+func (c Client) ListTeamsPage(ctx context.Context, limit int64, continueToken string) ([]Team, string, error) {
+	return c.listTeams(ctx, "player=true", limit, continueToken)
+}
+
+// ListSpectators lists the spectator teams, which ListTeams leaves out
+func (c Client) ListSpectators(ctx context.Context) ([]Team, error) {
+	teams, _, err := c.listTeams(ctx, "spectator=true", 0, "")
+	return teams, err
+}
+
+func (c Client) listTeams(ctx context.Context, selector string, limit int64, continueToken string) ([]Team, string, error) {
+	if c.EventName != "" {
+		selector += "," + PLEESAH_EVENT + "=" + c.EventName
+	}
+
 	namespaces, err := c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
-		LabelSelector: "player=true",
+		LabelSelector: selector,
+		Limit:         limit,
+		Continue:      continueToken,
 	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	teams := make([]Team, len(namespaces.Items))
@@ -185,17 +701,20 @@ func (c Client) ListTeams(ctx context.Context) ([]Team, error) {
 		teams[i] = team
 	}
 
-	return teams, nil
+	return teams, namespaces.Continue, nil
 }
 
 func namespaceToTeam(namespace apiv1.Namespace) Team {
 	annotations := namespace.GetAnnotations()
 	var progression []string
 	_ = json.Unmarshal([]byte(annotations[PLEESAH_COORDINATES]), &progression)
+	task, _ := strconv.Atoi(annotations[PLEESAH_TASK])
 	return Team{
 		Name:        namespace.Name,
 		Hexcode:     annotations[PLEESAH_HEXCODE],
 		Progression: progression,
+		Task:        task,
+		Created:     namespace.CreationTimestamp.Time,
 	}
 }
 
@@ -203,3 +722,21 @@ func (c Client) UpdateTeam(ctx context.Context, namespace *apiv1.Namespace) erro
 	_, err := c.client.CoreV1().Namespaces().Update(ctx, namespace, metav1.UpdateOptions{})
 	return err
 }
+
+// DeleteTeam deletes the team namespace, but only if havnesjef created it.
+func (c Client) DeleteTeam(ctx context.Context, team string) error {
+	namespace, err := c.getTeam(ctx, team)
+	if err != nil {
+		return err
+	}
+
+	if !c.inEvent(namespace) {
+		return k8serrors.NewNotFound(apiv1.Resource("namespaces"), team)
+	}
+
+	if namespace.Labels[MANAGED_BY] != "havnesjef" {
+		return ErrTeamNotManaged
+	}
+
+	return c.client.CoreV1().Namespaces().Delete(ctx, team, metav1.DeleteOptions{})
+}
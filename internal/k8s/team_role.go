@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"context"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TEAM_ROLE is the namespaced Role created in each player namespace when TeamRoleRules is set
+const TEAM_ROLE = "pleesah-team"
+
+// createTeamRole creates the TEAM_ROLE Role with TeamRoleRules in the team namespace, and binds it like the ClusterRole.
+func (c Client) createTeamRole(ctx context.Context, team string) error {
+	role := rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: TEAM_ROLE,
+			Labels: map[string]string{
+				MANAGED_BY: "havnesjef",
+			},
+		},
+		Rules: c.TeamRoleRules,
+	}
+	err := c.withRetry(func() error {
+		_, err := c.client.RbacV1().Roles(team).Create(ctx, &role, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	roleBinding := rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: TEAM_ROLE,
+		},
+		Subjects: c.roleBindingSubjects(team),
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "Role",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     TEAM_ROLE,
+		},
+	}
+	err = c.withRetry(func() error {
+		_, err := c.client.RbacV1().RoleBindings(team).Create(ctx, &roleBinding, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
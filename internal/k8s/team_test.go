@@ -0,0 +1,358 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testToken = "test-token-abc123"
+
+// newTestClient returns a client on a fake clientset where the player and spectator ClusterRoles
+// exist, and every token request is answered with testToken
+func newTestClient(t *testing.T, config Config, objects ...runtime.Object) (Client, *fake.Clientset) {
+	t.Helper()
+
+	objects = append(
+		objects,
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: PLAYER_ROLE}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: SPECTATOR_ROLE}},
+	)
+	clientset := fake.NewClientset(objects...)
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+
+		return true, &authenticationv1.TokenRequest{
+			Status: authenticationv1.TokenRequestStatus{
+				Token:               testToken,
+				ExpirationTimestamp: metav1.NewTime(time.Now().Add(config.TokenTTL)),
+			},
+		}, nil
+	})
+
+	client, err := New(clientset, nil, slog.New(slog.DiscardHandler), config)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	return client, clientset
+}
+
+func testConfig() Config {
+	config := DefaultConfig("kubernetes.example.com", "Y2EtZGF0YQ==")
+	config.Attempts = 1
+	return config
+}
+
+func TestSetupTeamResult(t *testing.T) {
+	client, clientset := newTestClient(t, testConfig())
+
+	result, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0)
+	if err != nil {
+		t.Fatalf("setting up team: %v", err)
+	}
+
+	if result.Namespace != "team-a" || result.ServiceAccount != "team-a" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	namespace, err := clientset.CoreV1().Namespaces().Get(context.Background(), "team-a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting namespace: %v", err)
+	}
+
+	if namespace.Labels["player"] != "true" || namespace.Annotations[PLEESAH_HEXCODE] != "#ff0000" {
+		t.Errorf("namespace is not labeled as a player team: %v %v", namespace.Labels, namespace.Annotations)
+	}
+
+	if namespace.Annotations[PLEESAH_TOKEN_EXPIRES] == "" {
+		t.Errorf("namespace has no %s annotation", PLEESAH_TOKEN_EXPIRES)
+	}
+}
+
+func TestSetupTeamResultTerminating(t *testing.T) {
+	terminating := &apiv1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "team-a",
+			Labels: map[string]string{MANAGED_BY: "havnesjef", "player": "true"},
+		},
+		Status: apiv1.NamespaceStatus{Phase: apiv1.NamespaceTerminating},
+	}
+	client, clientset := newTestClient(t, testConfig(), terminating)
+
+	_, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0)
+	if !errors.Is(err, ErrTeamTerminating) {
+		t.Fatalf("expected ErrTeamTerminating, got %v", err)
+	}
+
+	var setupErr *SetupError
+	if !errors.As(err, &setupErr) || setupErr.Code() != CODE_TEAM_TERMINATING {
+		t.Errorf("expected code %s, got %v", CODE_TEAM_TERMINATING, err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "create" {
+			t.Errorf("nothing should be created for a terminating team, got %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
+
+func TestSetupTeamResultBindMode(t *testing.T) {
+	tests := []struct {
+		bindMode string
+		want     rbacv1.Subject
+	}{
+		{
+			bindMode: BIND_GROUP,
+			want:     rbacv1.Subject{Kind: "Group", APIGroup: "rbac.authorization.k8s.io", Name: "system:serviceaccounts:team-a"},
+		},
+		{
+			bindMode: BIND_SERVICE_ACCOUNT,
+			want:     rbacv1.Subject{Kind: "ServiceAccount", Name: "team-a", Namespace: "team-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bindMode, func(t *testing.T) {
+			config := testConfig()
+			config.BindMode = tt.bindMode
+			client, clientset := newTestClient(t, config)
+
+			if _, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0); err != nil {
+				t.Fatalf("setting up team: %v", err)
+			}
+
+			roleBinding, err := clientset.RbacV1().RoleBindings("team-a").Get(context.Background(), "team-a", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("getting role binding: %v", err)
+			}
+
+			if len(roleBinding.Subjects) != 1 || roleBinding.Subjects[0] != tt.want {
+				t.Errorf("subjects = %+v, want [%+v]", roleBinding.Subjects, tt.want)
+			}
+
+			if roleBinding.RoleRef.Name != PLAYER_ROLE {
+				t.Errorf("role ref = %s, want %s", roleBinding.RoleRef.Name, PLAYER_ROLE)
+			}
+		})
+	}
+}
+
+func TestSetupTeamResultKeepsTokenOutOfLogs(t *testing.T) {
+	config := testConfig()
+	config.TokenSecretName = "havnesjef-token"
+	client, clientset := newTestClient(t, config)
+
+	var logs bytes.Buffer
+	client.log = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	// fail after the token is minted, both where it is only logged and where setup fails
+	clientset.PrependReactor("update", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("update refused")
+	})
+	clientset.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		secret := action.(k8stesting.CreateAction).GetObject().(*apiv1.Secret)
+		if secret.Name != config.TokenSecretName {
+			return false, nil, nil
+		}
+
+		return true, nil, errors.New("create refused")
+	})
+
+	_, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0)
+	var setupErr *SetupError
+	if !errors.As(err, &setupErr) || setupErr.Stage != STAGE_TOKEN {
+		t.Fatalf("expected setup to fail at %s, got %v", STAGE_TOKEN, err)
+	}
+
+	if strings.Contains(err.Error(), testToken) {
+		t.Errorf("error contains the token: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "failed annotating token expiry") {
+		t.Errorf("expected the failed annotation to be logged, got %s", logs.String())
+	}
+
+	if strings.Contains(logs.String(), testToken) {
+		t.Errorf("logs contain the token: %s", logs.String())
+	}
+}
+
+func TestSetupTeamResultWithoutSeedSecrets(t *testing.T) {
+	config := testConfig()
+	config.Seed.Secrets = nil
+	config.Seed.ConfigMaps = []ObjectSpec{{Name: "oppgave", Data: map[string]string{"README.md": "finn skatten"}}}
+	client, clientset := newTestClient(t, config)
+
+	result, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0)
+	if err != nil {
+		t.Fatalf("setting up team: %v", err)
+	}
+
+	if !strings.Contains(result.Kubeconfig, testToken) {
+		t.Errorf("kubeconfig has no token: %s", result.Kubeconfig)
+	}
+
+	secrets, err := clientset.CoreV1().Secrets("team-a").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("listing secrets: %v", err)
+	}
+
+	if len(secrets.Items) != 0 {
+		t.Errorf("expected no secrets, got %d", len(secrets.Items))
+	}
+
+	if _, err := clientset.CoreV1().ConfigMaps("team-a").Get(context.Background(), "oppgave", metav1.GetOptions{}); err != nil {
+		t.Errorf("config maps should still be seeded: %v", err)
+	}
+}
+
+func TestSetupTeamExec(t *testing.T) {
+	config := testConfig()
+	config.ExecSecret = []byte("hemmelig")
+	config.ExecURL = "https://havnesjef.example.com"
+	client, clientset := newTestClient(t, config)
+
+	result, err := client.SetupTeamExec(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, false)
+	if err != nil {
+		t.Fatalf("setting up team: %v", err)
+	}
+
+	if strings.Contains(result.Kubeconfig, testToken) || !strings.Contains(result.Kubeconfig, "havnesjef.example.com") {
+		t.Errorf("expected an exec kubeconfig without a token: %s", result.Kubeconfig)
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "create" && action.GetSubresource() == "token" {
+			t.Errorf("no token should be minted for an exec kubeconfig")
+		}
+	}
+
+	clientset.ClearActions()
+	_, err = client.SetupTeamExec(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, false)
+	var setupErr *SetupError
+	if !errors.Is(err, ErrTeamExists) || !errors.As(err, &setupErr) || setupErr.Code() != CODE_TEAM_EXISTS {
+		t.Fatalf("expected %s for an existing team, got %v", CODE_TEAM_EXISTS, err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("an existing team should be left alone, got %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+
+	replaced, err := client.SetupTeamExec(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, true)
+	if err != nil {
+		t.Fatalf("replacing the exec key: %v", err)
+	}
+
+	if replaced.Kubeconfig == result.Kubeconfig {
+		t.Errorf("expected a new exec key when replacing")
+	}
+}
+
+func TestTokensStayInEvent(t *testing.T) {
+	config := testConfig()
+	config.EventName = "vaar"
+	config.ExecSecret = []byte("hemmelig")
+	config.ExecURL = "https://havnesjef.example.com"
+
+	namespaces := map[string]map[string]string{
+		"team-host":      {MANAGED_BY: "havnesjef", "player": "true", PLEESAH_EVENT: "host"},
+		"team-unmanaged": {"player": "true", PLEESAH_EVENT: "vaar"},
+		"kube-system":    {MANAGED_BY: "havnesjef", PLEESAH_EVENT: "vaar"},
+	}
+
+	for name, labels := range namespaces {
+		t.Run(name, func(t *testing.T) {
+			namespace := &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      labels,
+				Annotations: map[string]string{PLEESAH_EXEC_NONCE: "nonce"},
+			}}
+			client, clientset := newTestClient(t, config, namespace)
+			ctx := context.Background()
+
+			if _, err := client.RenewToken(ctx, name); !k8serrors.IsNotFound(err) {
+				t.Errorf("RenewToken: expected NotFound, got %v", err)
+			}
+
+			if _, err := client.ReissueToken(ctx, name); !k8serrors.IsNotFound(err) {
+				t.Errorf("ReissueToken: expected NotFound, got %v", err)
+			}
+
+			if _, err := client.ExecCredential(ctx, name); !k8serrors.IsNotFound(err) {
+				t.Errorf("ExecCredential: expected NotFound, got %v", err)
+			}
+
+			if client.ValidExecKey(ctx, name, client.execKey(name, "nonce")) {
+				t.Errorf("ValidExecKey accepted a key for %s", name)
+			}
+
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() != "get" {
+					t.Errorf("expected only lookups, got %s %s", action.GetVerb(), action.GetResource().Resource)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupTeamResultTokenStillValid(t *testing.T) {
+	config := testConfig()
+	config.TokenRefreshWindow = time.Hour
+	existing := &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team-a",
+		Labels:      map[string]string{MANAGED_BY: "havnesjef", "player": "true"},
+		Annotations: map[string]string{PLEESAH_TOKEN_EXPIRES: time.Now().Add(12 * time.Hour).UTC().Format(time.RFC3339)},
+	}}
+	client, clientset := newTestClient(t, config, existing)
+
+	_, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0)
+	if !errors.Is(err, ErrTokenStillValid) {
+		t.Fatalf("expected ErrTokenStillValid, got %v", err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("nothing should change while the token is valid, got %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
+
+func TestExecCredentialKeepsTokenExpiry(t *testing.T) {
+	config := testConfig()
+	config.ExecSecret = []byte("hemmelig")
+	config.ExecURL = "https://havnesjef.example.com"
+	client, clientset := newTestClient(t, config)
+
+	if _, err := client.SetupTeamResult(context.Background(), "team-a", "#ff0000", PLAYER_ROLE, 0); err != nil {
+		t.Fatalf("setting up team: %v", err)
+	}
+
+	clientset.ClearActions()
+	if _, err := client.ExecCredential(context.Background(), "team-a"); err != nil {
+		t.Fatalf("creating exec credential: %v", err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "update" && action.GetResource().Resource == "namespaces" {
+			t.Errorf("exec credentials should not annotate the namespace")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// tokenRequestSupported asks discovery whether the cluster serves the serviceaccounts/token subresource
+func (c Client) tokenRequestSupported() (bool, error) {
+	resources, err := c.client.Discovery().ServerResourcesForGroupVersion("v1")
+	if err != nil {
+		return false, err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == "serviceaccounts/token" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// createTokenSecret is for clusters without the TokenRequest API. It creates a long-lived
+// service account token Secret, and waits for the token controller to fill in the token.
+// The token does not expire, so the returned expiration timestamp is zero.
+func (c Client) createTokenSecret(ctx context.Context, team string) (*authenticationv1.TokenRequest, error) {
+	name := team + "-token"
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				apiv1.ServiceAccountNameKey: team,
+			},
+		},
+		Type: apiv1.SecretTypeServiceAccountToken,
+	}
+
+	secrets := c.client.CoreV1().Secrets(team)
+	err := c.withRetry(func() error {
+		_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	var token string
+	err = wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		token = string(secret.Data[apiv1.ServiceAccountTokenKey])
+		return token != "", nil
+	})
+	if wait.Interrupted(err) {
+		return nil, fmt.Errorf("%w: token secret %s was never populated", ErrEmptyToken, name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger(ctx).Info("Using long-lived token secret, the cluster has no TokenRequest API", "team", team)
+	return &authenticationv1.TokenRequest{
+		Status: authenticationv1.TokenRequestStatus{Token: token},
+	}, nil
+}
+
+// publishToken writes token to the TokenSecretName Secret in the team namespace, so workloads can
+// mount it by name. It is updated in place each time the team gets a new token.
+func (c Client) publishToken(ctx context.Context, team string, token *authenticationv1.TokenRequest) error {
+	if c.TokenSecretName == "" {
+		return nil
+	}
+
+	data := map[string][]byte{
+		apiv1.ServiceAccountTokenKey: []byte(token.Status.Token),
+	}
+
+	if !token.Status.ExpirationTimestamp.IsZero() {
+		data["expires"] = []byte(token.Status.ExpirationTimestamp.UTC().Format(time.RFC3339))
+	}
+
+	secrets := c.client.CoreV1().Secrets(team)
+	return c.withRetry(func() error {
+		secret, err := secrets.Get(ctx, c.TokenSecretName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			secret = &apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: c.TokenSecretName,
+					Labels: map[string]string{
+						MANAGED_BY: "havnesjef",
+					},
+				},
+				Data: data,
+			}
+
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+
+		secret.Data = data
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
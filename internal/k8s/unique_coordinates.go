@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// UniqueCoordinatesProvider gives each team random coordinates no other team has. Assignments are kept
+// in a ConfigMap keyed by team, so they survive restarts and are shared between replicas.
+// Assignments are never released when a team is deleted, so a team joining again under the same name gets its
+// old coordinates back. The client is set by New.
+type UniqueCoordinatesProvider struct {
+	Namespace string
+	Name      string
+
+	client kubernetes.Interface
+	mu     sync.Mutex
+	// random draws coordinates, and can be swapped for a predictable source
+	random func() string
+}
+
+func NewUniqueCoordinatesProvider(namespace, name string) *UniqueCoordinatesProvider {
+	return &UniqueCoordinatesProvider{
+		Namespace: namespace,
+		Name:      name,
+		random:    randomCoordinates,
+	}
+}
+
+// Generate returns the coordinates assigned to team, assigning new ones if it has none
+func (p *UniqueCoordinatesProvider) Generate(team string) (map[string][]byte, error) {
+	return p.assign(team, false)
+}
+
+// Regenerate assigns team new coordinates, releasing the ones it had
+func (p *UniqueCoordinatesProvider) Regenerate(team string) (map[string][]byte, error) {
+	return p.assign(team, true)
+}
+
+func (p *UniqueCoordinatesProvider) assign(team string, replace bool) (map[string][]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var coordinates string
+	// another replica may assign coordinates at the same time, which shows up as a conflict on update
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := p.configMap(ctx)
+		if err != nil {
+			return err
+		}
+
+		if assigned, ok := configMap.Data[team]; ok && !replace {
+			coordinates = assigned
+			return nil
+		}
+
+		coordinates, err = p.unused(configMap.Data)
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+
+		configMap.Data[team] = coordinates
+		_, err = p.client.CoreV1().ConfigMaps(p.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed assigning coordinates in config map %s/%s: %w", p.Namespace, p.Name, err)
+	}
+
+	return map[string][]byte{
+		COORDINATES_KEY: []byte(coordinates),
+	}, nil
+}
+
+// unused draws coordinates until it finds some that are not assigned yet
+func (p *UniqueCoordinatesProvider) unused(assigned map[string]string) (string, error) {
+	taken := slices.Collect(maps.Values(assigned))
+
+	for range 100 {
+		coordinates := p.random()
+		if !slices.Contains(taken, coordinates) {
+			return coordinates, nil
+		}
+	}
+
+	return "", fmt.Errorf("found no unused coordinates among %d assigned", len(taken))
+}
+
+func (p *UniqueCoordinatesProvider) configMap(ctx context.Context) (*apiv1.ConfigMap, error) {
+	configMaps := p.client.CoreV1().ConfigMaps(p.Namespace)
+	configMap, err := configMaps.Get(ctx, p.Name, metav1.GetOptions{})
+	if !k8serrors.IsNotFound(err) {
+		return configMap, err
+	}
+
+	configMap = &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: p.Name,
+			Labels: map[string]string{
+				MANAGED_BY: "havnesjef",
+			},
+		},
+	}
+
+	configMap, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		return configMaps.Get(ctx, p.Name, metav1.GetOptions{})
+	}
+
+	return configMap, err
+}
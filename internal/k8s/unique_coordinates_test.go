@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestCoordinatesProvider(clientset *fake.Clientset, random func() string) *UniqueCoordinatesProvider {
+	provider := NewUniqueCoordinatesProvider("pleesah-system", "havnesjef-coordinates")
+	provider.client = clientset
+	provider.random = random
+	return provider
+}
+
+func TestUniqueCoordinatesSkipsAssigned(t *testing.T) {
+	clientset := fake.NewClientset(&apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "havnesjef-coordinates", Namespace: "pleesah-system"},
+		Data:       map[string]string{"team-a": "1,1"},
+	})
+
+	draws := []string{"1,1", "2,2"}
+	provider := newTestCoordinatesProvider(clientset, func() string {
+		next := draws[0]
+		draws = draws[1:]
+		return next
+	})
+
+	secret, err := provider.Generate("team-b")
+	if err != nil {
+		t.Fatalf("generating coordinates: %v", err)
+	}
+
+	if got := string(secret[COORDINATES_KEY]); got != "2,2" {
+		t.Errorf("coordinates = %s, want 2,2 since 1,1 is taken", got)
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps("pleesah-system").Get(context.Background(), "havnesjef-coordinates", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting config map: %v", err)
+	}
+
+	if configMap.Data["team-b"] != "2,2" || configMap.Data["team-a"] != "1,1" {
+		t.Errorf("unexpected assignments: %v", configMap.Data)
+	}
+}
+
+func TestUniqueCoordinatesReload(t *testing.T) {
+	clientset := fake.NewClientset()
+	first := newTestCoordinatesProvider(clientset, func() string { return "3,4" })
+
+	if _, err := first.Generate("team-a"); err != nil {
+		t.Fatalf("generating coordinates: %v", err)
+	}
+
+	// a restarted or second replica only knows the config map
+	second := newTestCoordinatesProvider(clientset, func() string {
+		t.Fatal("coordinates assigned earlier should be reused, not drawn again")
+		return ""
+	})
+
+	secret, err := second.Generate("team-a")
+	if err != nil {
+		t.Fatalf("generating coordinates: %v", err)
+	}
+
+	if got := string(secret[COORDINATES_KEY]); got != "3,4" {
+		t.Errorf("coordinates = %s, want the earlier 3,4", got)
+	}
+}
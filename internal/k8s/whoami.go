@@ -0,0 +1,45 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type TokenIdentity struct {
+	Authenticated bool   `json:"authenticated"`
+	Username      string `json:"username,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ReviewToken asks the API server who token belongs to. Service account tokens also get their namespace,
+// which is the team for tokens handed out by havnesjef.
+func (c Client) ReviewToken(ctx context.Context, token string) (TokenIdentity, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: c.Audiences,
+		},
+	}
+
+	result, err := c.client.AuthenticationV1().TokenReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return TokenIdentity{}, err
+	}
+
+	identity := TokenIdentity{
+		Authenticated: result.Status.Authenticated,
+		Username:      result.Status.User.Username,
+		Error:         result.Status.Error,
+	}
+
+	// service account usernames are system:serviceaccount:<namespace>:<name>
+	if rest, ok := strings.CutPrefix(identity.Username, "system:serviceaccount:"); ok {
+		identity.Namespace, _, _ = strings.Cut(rest, ":")
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var teamsActive = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "pleesah_teams_active",
+	Help: "Number of teams that currently exist",
+})
+
+type teamLister interface {
+	ListTeams(ctx context.Context) ([]k8s.Team, error)
+}
+
+// RecordActiveTeams counts the teams every interval until ctx is done. Scrapes only read the gauge,
+// so they never reach the API server, and the value can be up to interval old.
+func RecordActiveTeams(ctx context.Context, log *slog.Logger, client teamLister, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		teams, err := client.ListTeams(ctx)
+		if err != nil {
+			log.Warn("failed counting active teams", "error", err)
+		} else {
+			teamsActive.Set(float64(len(teams)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
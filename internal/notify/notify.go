@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Notifier posts a message to a webhook, e.g. a Slack incoming webhook, when a team is created.
+// A nil Notifier sends nothing.
+type Notifier struct {
+	url    string
+	client *http.Client
+}
+
+type Message struct {
+	// Text is what Slack shows, the other fields are for webhooks reading JSON
+	Text      string    `json:"text"`
+	Team      string    `json:"team"`
+	Role      string    `json:"role"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// New returns a Notifier posting to url, or nil when url is empty
+func New(url string) *Notifier {
+	if url == "" {
+		return nil
+	}
+
+	return &Notifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// TeamCreated sends the message in the background, so a slow or failing webhook never holds up the team.
+// Delivery errors are only logged.
+func (n *Notifier) TeamCreated(log *slog.Logger, team, role string) {
+	if n == nil {
+		return
+	}
+
+	message := Message{
+		Text:      fmt.Sprintf("Team %s har blitt med som %s", team, role),
+		Team:      team,
+		Role:      role,
+		Timestamp: time.Now().UTC(),
+	}
+
+	go func() {
+		if err := n.send(message); err != nil {
+			log.Warn("failed notifying webhook", "error", err, "team", team)
+		}
+	}()
+}
+
+func (n *Notifier) send(message Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package request
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+type idKey struct{}
+
+var validID = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// WithID returns a copy of ctx carrying the request id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// ID returns the request id from ctx, or an empty string if there is none.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// NewID generates a random request id.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidID reports whether an id from a client is safe to reuse in logs.
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
@@ -0,0 +1,49 @@
+package version
+
+import "runtime/debug"
+
+// Set at build time with -ldflags "-X github.com/navikt/pleesah-havnesjef/internal/version.version=..."
+var (
+	version string
+	commit  string
+	date    string
+)
+
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// Get returns the build info set by ldflags, falling back to what the Go toolchain embedded in the binary.
+func Get() Info {
+	info := Info{
+		Version: version,
+		Commit:  commit,
+		Date:    date,
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if info.Version == "" {
+		info.Version = buildInfo.Main.Version
+	}
+
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	return info
+}
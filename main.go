@@ -1,47 +1,116 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/navikt/pleesah-havnesjef/internal/api"
+	"github.com/navikt/pleesah-havnesjef/internal/config"
 	"github.com/navikt/pleesah-havnesjef/internal/k8s"
+	"github.com/navikt/pleesah-havnesjef/internal/metrics"
+	"github.com/navikt/pleesah-havnesjef/internal/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
 func main() {
-	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
-
-	endpoint := os.Getenv("ENDPOINT")
-	if endpoint == "" {
-		panic(fmt.Errorf("ENDPOINT is not set"))
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
 	}
 
-	ca := os.Getenv("CA")
-	if ca == "" {
-		panic(fmt.Errorf("CA is not set"))
+	log, err := newLogger(cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		panic(err)
 	}
 
-	kubeconfig := findKubeconfig(log, endpoint, ca)
+	info := version.Get()
+	log.Info("Starting havnesjef", "version", info.Version, "commit", info.Commit, "date", info.Date)
+	if path := os.Getenv("CONFIG"); path != "" {
+		log.Info("Using config from file", "path", path)
+	}
+	log.Info("Startup configuration", "config", cfg)
 
-	// use the current context in kubeconfig
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	restConfig, err := loadRestConfig(log, findKubeconfig(log, cfg.Endpoint, cfg.CA))
 	if err != nil {
 		panic(err.Error())
 	}
 
 	// create the clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
 	if err != nil {
 		panic(err.Error())
 	}
 
-	api := api.New(k8s.New(clientset, log.WithGroup("k8s"), endpoint, ca), log.WithGroup("api"))
-	api.Run()
+	k8sConfig, err := cfg.K8s()
+	if err != nil {
+		panic(err)
+	}
+
+	client, err := k8s.New(clientset, dynamicClient, log.WithGroup("k8s"), k8sConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := client.ValidateKubeconfigs(); err != nil {
+		panic(fmt.Errorf("kubeconfigs for teams are broken: %w", err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// checked for below, but looking it up is still a permission havnesjef needs
+	client.PriorityClass = cfg.PriorityClass
+	missing, err := client.MissingPermissions(ctx)
+	if err != nil {
+		log.Warn("failed checking permissions", "error", err)
+	} else if len(missing) > 0 {
+		panic(fmt.Errorf("havnesjef is missing permissions needed for setting up teams: %s", strings.Join(missing, ", ")))
+	}
+
+	if cfg.InsecureKubeconfig {
+		log.Warn("INSECURE_KUBECONFIG is set, kubeconfigs handed out skip TLS verification and are open to man-in-the-middle attacks")
+	}
+
+	if cfg.PriorityClass != "" {
+		exists, err := client.PriorityClassExists(ctx, cfg.PriorityClass)
+		switch {
+		case err != nil:
+			log.Warn("failed checking priority class, skipping it", "error", err, "priorityClass", cfg.PriorityClass)
+			client.PriorityClass = ""
+		case !exists:
+			log.Warn("priority class does not exist, skipping it", "priorityClass", cfg.PriorityClass)
+			client.PriorityClass = ""
+		}
+	}
+
+	if err := client.EnsureClusterRole(ctx); err != nil {
+		panic(fmt.Errorf("failed ensuring ClusterRole: %s", err))
+	}
+
+	go metrics.RecordActiveTeams(ctx, log.WithGroup("metrics"), client, cfg.MetricsInterval.Duration)
+
+	apiConfig, err := cfg.API()
+	if err != nil {
+		panic(err)
+	}
+
+	api := api.New(client, log.WithGroup("api"), apiConfig)
+	api.Run(ctx, cfg.TLSCert, cfg.TLSKey)
 }
 
 func findKubeconfig(log *slog.Logger, endpoint, ca string) string {
@@ -57,13 +126,50 @@ func findKubeconfig(log *slog.Logger, endpoint, ca string) string {
 	} else {
 		kubeconfigEnv := os.Getenv("KUBECONFIG")
 		if kubeconfigEnv != "" {
-			log.Info("Using config from env")
+			log.Info("Using kubeconfig from env")
 			kubeconfig = kubeconfigEnv
 		} else if home := homedir.HomeDir(); home != "" {
-			log.Info("Using config from .kube")
+			log.Info("Looking for kubeconfig in .kube")
 			kubeconfig = filepath.Join(home, ".kube", "config")
 		}
 	}
 
 	return kubeconfig
 }
+
+// loadRestConfig uses the current context in kubeconfig, or the in-cluster config when kubeconfig does not exist
+func loadRestConfig(log *slog.Logger, kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		if _, err := os.Stat(kubeconfig); err == nil {
+			log.Info("Using kubeconfig", "path", kubeconfig)
+			return clientcmd.BuildConfigFromFlags("", kubeconfig)
+		}
+	}
+
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("no kubeconfig at %q and not running in cluster: %w", kubeconfig, err)
+	}
+
+	log.Info("Using in-cluster config")
+	return config, nil
+}
+
+func newLogger(format, level string) (*slog.Logger, error) {
+	var logLevel slog.Level
+	if level != "" {
+		if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL is not valid: %s", level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: logLevel}
+	switch format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stdout, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts)), nil
+	default:
+		return nil, fmt.Errorf("LOG_FORMAT is not valid: %s", format)
+	}
+}